@@ -57,7 +57,7 @@ func main() {
 
 	time.Sleep(3 * time.Second)
 
-	cli.Seek(context.Background(), 35)
+	cli.Seek(context.Background(), 35*time.Second)
 	pos, _ = cli.GetPositionInfo(context.Background())
 	log.Printf("%+v", pos)
 }
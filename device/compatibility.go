@@ -0,0 +1,163 @@
+package device
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/supersonic-app/go-upnpcast/internal/utils"
+	"github.com/supersonic-app/go-upnpcast/services/avtransport"
+	"github.com/supersonic-app/go-upnpcast/services/connectionmanager"
+)
+
+// MediaSupport is the result of checking whether a renderer can play a media item
+type MediaSupport struct {
+	// Whether the renderer accepts the media's MIME type
+	Supported bool
+
+	// The protocolInfo of the media, with its MIME type and DLNA parameters,
+	// in the form accepted by the renderer's best matching sink protocolInfo.
+	// Can be set as the MediaItem's ProtocolInfo.
+	ProtocolInfo connectionmanager.ProtocolInfo
+
+	// Why the media is not supported, if Supported is false. If Supported is true,
+	// why the media may still not play, e.g. when the renderer requires a DLNA profile
+	// that the media is not known to conform to.
+	Reason string
+}
+
+// common alternative spellings of MIME types used by renderers
+var mimeTypeAliases = map[string]string{
+	"audio/x-flac":    "audio/flac",
+	"audio/mp3":       "audio/mpeg",
+	"audio/x-mp3":     "audio/mpeg",
+	"audio/x-mpeg":    "audio/mpeg",
+	"audio/wave":      "audio/wav",
+	"audio/x-wav":     "audio/wav",
+	"audio/x-m4a":     "audio/mp4",
+	"audio/m4a":       "audio/mp4",
+	"audio/x-aac":     "audio/aac",
+	"audio/x-ogg":     "audio/ogg",
+	"audio/x-ms-wma":  "audio/wma",
+	"video/x-mkv":     "video/x-matroska",
+	"video/x-mp4":     "video/mp4",
+	"video/x-msvideo": "video/avi",
+}
+
+// CanPlay checks the media item's ContentType against the protocols
+// the renderer reports it can receive through the ConnectionManager service.
+// Renderers that do not report any protocols are assumed to support the media.
+func (m *MediaRenderer) CanPlay(ctx context.Context, media *avtransport.MediaItem) (MediaSupport, error) {
+	cm, err := m.ConnectionManagerClient()
+	if err != nil {
+		return MediaSupport{}, err
+	}
+	protocols, err := cm.GetProtocolInfo(ctx)
+	if err != nil {
+		return MediaSupport{}, fmt.Errorf("CanPlay error: %w", err)
+	}
+	return matchSinkProtocol(protocols.Sink, media.ContentType, media.Seekable), nil
+}
+
+// match quality of a sink protocolInfo, higher is better
+const (
+	matchNone = iota
+	matchWildcard
+	matchWildcardSubtype
+	matchMIMEType
+	matchMIMETypeNoProfile
+	matchMIMETypeAndProfile
+)
+
+func matchSinkProtocol(sinks []connectionmanager.ProtocolInfo, contentType string, seekable bool) MediaSupport {
+	mimeType := normalizeMIMEType(contentType)
+	if mimeType == "" {
+		return MediaSupport{Reason: "media item has no content type"}
+	}
+	if len(sinks) == 0 {
+		return MediaSupport{Supported: true}
+	}
+	profile := utils.DLNAProfile(mimeType)
+
+	best, bestQuality := connectionmanager.ProtocolInfo{}, matchNone
+	for _, sink := range sinks {
+		if sink.Protocol != "http-get" {
+			continue
+		}
+		if q := matchQuality(sink, mimeType, profile); q > bestQuality {
+			best, bestQuality = sink, q
+		}
+	}
+
+	if bestQuality == matchNone {
+		return MediaSupport{Reason: fmt.Sprintf("renderer does not accept %s over HTTP", mimeType)}
+	}
+	support := MediaSupport{Supported: true, ProtocolInfo: mediaProtocolInfo(best, contentType, profile, seekable)}
+	if bestQuality == matchMIMEType {
+		support.Reason = fmt.Sprintf("renderer accepts %s only with DLNA profile %s", mimeType, best.DLNAProfile)
+	}
+	return support
+}
+
+// mediaProtocolInfo returns the protocolInfo of the media matched by the sink.
+// The sink's MIME type and DLNA profile are used where they are concrete and match the media's,
+// since they are the spelling the renderer expects; otherwise the media's are used.
+func mediaProtocolInfo(sink connectionmanager.ProtocolInfo, contentType, profile string, seekable bool) connectionmanager.ProtocolInfo {
+	mimeType := sink.MIMEType
+	if strings.Contains(mimeType, "*") {
+		var err error
+		if mimeType, _, err = mime.ParseMediaType(contentType); err != nil {
+			mimeType = normalizeMIMEType(contentType)
+		}
+	}
+	// never claim a profile the media may not conform to
+	if profile != "" && strings.EqualFold(sink.DLNAProfile, profile) {
+		profile = sink.DLNAProfile
+	}
+
+	seek := "00"
+	if seekable {
+		seek = "01"
+	}
+	// the MIME type is only used for the profile, which is added below
+	additionalInfo, _ := utils.BuildContentFeatures("", seek, false /*transcode*/)
+	if profile != "" {
+		additionalInfo = "DLNA.ORG_PN=" + profile + ";" + additionalInfo
+	}
+
+	info, _ := connectionmanager.ParseProtocolInfo("http-get:*:" + mimeType + ":" + additionalInfo)
+	return info
+}
+
+func matchQuality(sink connectionmanager.ProtocolInfo, mimeType, profile string) int {
+	sinkType := normalizeMIMEType(sink.MIMEType)
+	switch {
+	case sinkType == "*" || sinkType == "*/*":
+		return matchWildcard
+	case strings.HasSuffix(sinkType, "/*"):
+		if strings.HasPrefix(mimeType, strings.TrimSuffix(sinkType, "*")) {
+			return matchWildcardSubtype
+		}
+		return matchNone
+	case sinkType != mimeType:
+		return matchNone
+	case profile != "" && strings.EqualFold(sink.DLNAProfile, profile):
+		return matchMIMETypeAndProfile
+	case sink.DLNAProfile == "":
+		return matchMIMETypeNoProfile
+	}
+	// the sink requires a specific DLNA profile, which the media may or may not conform to
+	return matchMIMEType
+}
+
+func normalizeMIMEType(mimeType string) string {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(mimeType))
+	}
+	if alias, ok := mimeTypeAliases[mediaType]; ok {
+		return alias
+	}
+	return mediaType
+}
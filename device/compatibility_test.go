@@ -0,0 +1,47 @@
+package device
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/supersonic-app/go-upnpcast/services/connectionmanager"
+)
+
+func TestMatchSinkProtocol(t *testing.T) {
+	sinks := connectionmanager.ParseProtocolInfoList(
+		"http-get:*:audio/mpeg:DLNA.ORG_PN=MP3X,http-get:*:audio/mpeg:DLNA.ORG_PN=MP3,http-get:*:audio/x-flac:*," +
+			"rtsp-rtp-udp:*:audio/ogg:*,http-get:*:video/*:*")
+
+	tt := []struct {
+		name          string
+		sinks         []connectionmanager.ProtocolInfo
+		contentType   string
+		wantSupported bool
+		wantProtocol  string
+		wantReason    bool
+	}{
+		{`matchSinkProtocol DLNA profile`, sinks, "audio/mpeg", true, "http-get:*:audio/mpeg:DLNA.ORG_PN=MP3;DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=", false},
+		{`matchSinkProtocol MIME alias`, sinks, "audio/flac", true, "http-get:*:audio/x-flac:DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=", false},
+		{`matchSinkProtocol MIME params`, sinks, "Audio/FLAC; rate=44100", true, "http-get:*:audio/x-flac:DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=", false},
+		{`matchSinkProtocol wildcard subtype`, sinks, "video/mp4", true, "http-get:*:video/mp4:DLNA.ORG_PN=AVC_MP4_MP_SD_AAC_MULT5;DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=", false},
+		{`matchSinkProtocol wildcard`, connectionmanager.ParseProtocolInfoList("http-get:*:*:*"), "audio/ogg", true, "http-get:*:audio/ogg:DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=", false},
+		{`matchSinkProtocol other DLNA profile`, connectionmanager.ParseProtocolInfoList("http-get:*:audio/mpeg:DLNA.ORG_PN=MP3X"), "audio/mpeg", true, "http-get:*:audio/mpeg:DLNA.ORG_PN=MP3;DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=", true},
+		{`matchSinkProtocol not HTTP`, sinks, "audio/ogg", false, ":::", true},
+		{`matchSinkProtocol unsupported`, sinks, "audio/opus", false, ":::", true},
+		{`matchSinkProtocol no content type`, sinks, "", false, ":::", true},
+		{`matchSinkProtocol no sinks reported`, nil, "audio/opus", true, ":::", false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out := matchSinkProtocol(tc.sinks, tc.contentType, true)
+			// the DLNA flags are not checked
+			if out.Supported != tc.wantSupported || !strings.HasPrefix(out.ProtocolInfo.String(), tc.wantProtocol) {
+				t.Fatalf("%s: got: %v %s, want: %v %s.", tc.name, out.Supported, out.ProtocolInfo, tc.wantSupported, tc.wantProtocol)
+			}
+			if (out.Reason != "") != tc.wantReason {
+				t.Fatalf("%s: got reason: %q, want reason: %v.", tc.name, out.Reason, tc.wantReason)
+			}
+		})
+	}
+}
@@ -1,20 +1,44 @@
 package device
 
 import (
-	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"slices"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/koron/go-ssdp"
+	"github.com/supersonic-app/go-upnpcast/internal/scpd"
 	"github.com/supersonic-app/go-upnpcast/services"
 	"github.com/supersonic-app/go-upnpcast/services/avtransport"
+	"github.com/supersonic-app/go-upnpcast/services/connectionmanager"
 	"github.com/supersonic-app/go-upnpcast/services/renderingcontrol"
 )
 
 var ErrUnsupportedService = errors.New("the device does not support the requested service")
 
+// ExcludedDeviceError is reported by SearchMediaRenderers for each device that was
+// found on the LAN but excluded from the results because it does not support
+// all of the required services.
+type ExcludedDeviceError struct {
+	// The excluded device
+	Device *MediaRenderer
+
+	// The required service types the device does not support
+	MissingServices []services.Type
+}
+
+func (e *ExcludedDeviceError) Error() string {
+	return fmt.Sprintf("device %q (%s) excluded: unsupported services %s",
+		e.Device.FriendlyName, e.Device.URL, strings.Join(e.MissingServices, ", "))
+}
+
+func (e *ExcludedDeviceError) Unwrap() error {
+	return ErrUnsupportedService
+}
+
 // MediaRenderer represents a Digital Media Renderer (DMR) device discovered on the LAN
 type MediaRenderer struct {
 	// URL for the device's service descrption manifest
@@ -26,55 +50,180 @@ type MediaRenderer struct {
 	// Model name of the device
 	ModelName string
 
-	avTransportControlURL  string
-	avTransportEventSubURL string
-	renderingControlURL    string
-	connectionManagerURL   string
+	// Unique device name, e.g. "uuid:4d696e69-444c-164e-9d41-b827eb54e939".
+	// Unlike URL, it does not change when the device's IP address changes,
+	// so it should be used to identify the device across restarts.
+	UDN string
+
+	// Device type, e.g. "urn:schemas-upnp-org:device:MediaRenderer:1"
+	DeviceType string
+
+	Manufacturer     string
+	ManufacturerURL  string
+	ModelNumber      string
+	ModelDescription string
+	SerialNumber     string
+
+	// URL of the device's web interface, if any
+	PresentationURL string
+
+	// Icons advertised by the device; see BestIcon
+	Icons []Icon
+
+	// Local network interface on which the device responded to the SSDP search,
+	// and the local address the search was sent from.
+	// Nil if the device was not found by a multicast search.
+	Interface *net.Interface
+	LocalAddr net.IP
+
+	avTransportControlURL   string
+	avTransportEventSubURL  string
+	renderingControlURL     string
+	renderingControlSCPDURL string
+	connectionManagerURL    string
+
+	// service types as advertised in the device description, including their version
+	serviceTypes        []services.Type
+	serviceDescriptions map[services.Type]*scpd.Document
 }
 
 // SearchMediaRenderers searches for MediaRenderer devices on the LAN
 // that implement all the service types specified in `requiredServices`
 // - waitSec is how many seconds to wait for device responses to the SSDP search
 // If passing a context with deadline/expiration, it should be longer than waitSec
+//
+// The returned devices are valid even if a non-nil error is also returned.
+// The error joins the errors for every device that could not be loaded, and an
+// *ExcludedDeviceError for every device that lacks one of the required services.
+//
+// The search is sent on all suitable network interfaces;
+// see SearchMediaRenderersWithOptions to choose the interfaces.
 func SearchMediaRenderers(ctx context.Context, waitSec int, requiredServices ...services.Type) ([]*MediaRenderer, error) {
-	deviceLocations, err := getSSDPAVTransportDeviceLocations(waitSec)
-	if err != nil {
-		return nil, err
+	return SearchMediaRenderersWithOptions(ctx, SearchOptions{WaitSec: waitSec, RequiredServices: requiredServices})
+}
+
+// loadMediaRenderers loads the renderers described at each location
+// that implement all the required services.
+// Descriptions are fetched in parallel, each with its own timeout,
+// so that a device that does not respond does not hold up the others.
+func loadMediaRenderers(ctx context.Context, deviceLocations []deviceLocation, opts SearchOptions) ([]*MediaRenderer, error) {
+	results := make([][]*MediaRenderer, len(deviceLocations))
+	errs := make([]error, len(deviceLocations))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.fetchWorkers())
+	for i, l := range deviceLocations {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = loadLocation(ctx, l, opts.fetchTimeout())
+		}()
 	}
+	wg.Wait()
 
 	devices := make([]*MediaRenderer, 0, len(deviceLocations))
-	errors := []error{}
-	for _, l := range deviceLocations {
-		mr, err := mediaRendererFromDeviceURL(ctx, l)
-		if err != nil {
-			errors = append(errors, err)
-			continue
+	var udns listSet
+	for _, renderers := range results {
+		for _, mr := range renderers {
+			// skip devices found at more than one location, e.g. on several interfaces
+			if mr.UDN != "" && !udns.add(mr.UDN) {
+				continue
+			}
+			if missing := mr.missingServices(opts.RequiredServices); len(missing) > 0 {
+				errs = append(errs, &ExcludedDeviceError{Device: mr, MissingServices: missing})
+				continue
+			}
+			devices = append(devices, mr)
 		}
-		devices = append(devices, mr)
 	}
 
-	return devices, cmp.Or(errors...)
+	return devices, errors.Join(errs...)
+}
+
+// loadLocation loads the renderers described at the location within the timeout
+func loadLocation(ctx context.Context, l deviceLocation, timeout time.Duration) ([]*MediaRenderer, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	renderers, err := mediaRenderersFromDeviceURL(ctx, l.url)
+	for _, mr := range renderers {
+		mr.Interface, mr.LocalAddr = l.iface, l.localAddr
+	}
+	if err != nil {
+		err = fmt.Errorf("%s: %w", l.url, err)
+	}
+	return renderers, err
 }
 
-// SupportsService returns true if the MediaRenderer supports the given service type
+// SupportsService returns true if the MediaRenderer supports the given service type.
+// A device advertising a later version of the service also supports the earlier versions.
 func (m *MediaRenderer) SupportsService(serviceType services.Type) bool {
-	switch serviceType {
-	case services.AVTransport:
+	if m.advertisedServiceType(serviceType) == "" {
+		return false
+	}
+	switch {
+	case services.Implements(serviceType, services.AVTransport):
 		return m.avTransportControlURL != "" && m.avTransportEventSubURL != ""
-	case services.ConnectionManager:
+	case services.Implements(serviceType, services.ConnectionManager):
 		return m.connectionManagerURL != ""
-	case services.RenderingControl:
+	case services.Implements(serviceType, services.RenderingControl):
 		return m.renderingControlURL != ""
 	}
 	return false
 }
 
+// ServiceVersion returns the version of the given service advertised by the device,
+// e.g. 2 for a device implementing AVTransport:2,
+// or 0 if the device does not support the service.
+func (m *MediaRenderer) ServiceVersion(serviceType services.Type) int {
+	return services.Version(m.advertisedServiceType(serviceType))
+}
+
+// ServiceDescription returns the description of the actions and state variables
+// implemented by the given service, or nil if the device does not support the service
+// or its description could not be fetched.
+func (m *MediaRenderer) ServiceDescription(serviceType services.Type) *ServiceDescription {
+	return m.serviceDescriptions[m.advertisedServiceType(serviceType)]
+}
+
+// SupportsAction returns true if the service's description lists the given action.
+// Returns false if the description is not available; see ServiceDescription.
+func (m *MediaRenderer) SupportsAction(serviceType services.Type, actionName string) bool {
+	desc := m.ServiceDescription(serviceType)
+	return desc != nil && desc.Action(actionName) != nil
+}
+
+func (m *MediaRenderer) missingServices(serviceTypes []services.Type) []services.Type {
+	var missing []services.Type
+	for _, s := range serviceTypes {
+		if !m.SupportsService(s) {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// advertisedServiceType returns the type, as advertised by the device,
+// of the service that implements the given service type, or "" if there is none
+func (m *MediaRenderer) advertisedServiceType(serviceType services.Type) services.Type {
+	for _, t := range m.serviceTypes {
+		if services.Implements(t, serviceType) {
+			return t
+		}
+	}
+	return ""
+}
+
 // AVTransportClient returns a new client to the device's AVTransport service.
 func (m *MediaRenderer) AVTransportClient() (*avtransport.Client, error) {
 	if !m.SupportsService(services.AVTransport) {
 		return nil, ErrUnsupportedService
 	}
-	return avtransport.NewClient(m.avTransportControlURL, m.avTransportEventSubURL), nil
+	c := avtransport.NewClient(m.avTransportControlURL, m.avTransportEventSubURL)
+	c.ServiceType = m.advertisedServiceType(services.AVTransport)
+	return c, nil
 }
 
 // RenderingControlClient returns a new client to the device's RenderingControl service.
@@ -82,31 +231,38 @@ func (m *MediaRenderer) RenderingControlClient() (*renderingcontrol.Client, erro
 	if !m.SupportsService(services.RenderingControl) {
 		return nil, ErrUnsupportedService
 	}
-	return renderingcontrol.NewClient(m.renderingControlURL), nil
+	c := renderingcontrol.NewClient(m.renderingControlURL)
+	c.ServiceType = m.advertisedServiceType(services.RenderingControl)
+	// reuse the description fetched during discovery, if any
+	c.ServiceDescription = m.ServiceDescription(services.RenderingControl)
+	c.SCPDURL = m.renderingControlSCPDURL
+	return c, nil
 }
 
-// Gets the list of DMR schema URLs for all found devices that support the AVTransport service
-func getSSDPAVTransportDeviceLocations(waitSec int) ([]string, error) {
-	ssdpServices, err := ssdp.Search(ssdp.All, waitSec, "")
-	if err != nil {
-		return nil, fmt.Errorf("SSDP search error: %w", err)
+// ConnectionManagerClient returns a new client to the device's ConnectionManager service.
+func (m *MediaRenderer) ConnectionManagerClient() (*connectionmanager.Client, error) {
+	if !m.SupportsService(services.ConnectionManager) {
+		return nil, ErrUnsupportedService
 	}
+	c := connectionmanager.NewClient(m.connectionManagerURL)
+	c.ServiceType = m.advertisedServiceType(services.ConnectionManager)
+	return c, nil
+}
 
-	var deviceLocations listSet
-	for _, srv := range ssdpServices {
-		// All DMRs we care about must support the AVTransport service
-		if srv.Type == services.AVTransport {
-			deviceLocations.add(srv.Location)
-		}
-	}
-	return deviceLocations, nil
+// udnFromUSN returns the unique device name prefix of an SSDP unique service name
+// e.g. "uuid:device-UUID::urn:schemas-upnp-org:service:AVTransport:1" -> "uuid:device-UUID"
+func udnFromUSN(usn string) string {
+	udn, _, _ := strings.Cut(usn, "::")
+	return udn
 }
 
 type listSet []string
 
-func (l *listSet) add(s string) {
+// add adds s to the set, returning false if it was already present
+func (l *listSet) add(s string) bool {
 	if slices.Contains(*l, s) {
-		return
+		return false
 	}
 	*l = append(*l, s)
+	return true
 }
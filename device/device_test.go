@@ -0,0 +1,473 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supersonic-app/go-upnpcast/services"
+)
+
+const testDeviceDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:MediaRenderer:1</deviceType>
+    <friendlyName>%s</friendlyName>
+    <modelName>Test Model</modelName>
+    <serviceList>%s</serviceList>
+  </device>
+</root>`
+
+const testServiceDescription = `
+      <service>
+        <serviceType>%s</serviceType>
+        <serviceId>%s</serviceId>
+        <SCPDURL>/%s/scpd.xml</SCPDURL>
+        <controlURL>/%s/control</controlURL>
+        <eventSubURL>/%s/event</eventSubURL>
+      </service>`
+
+func testDescription(name string, serviceTypes ...services.Type) string {
+	var svcs strings.Builder
+	for _, st := range serviceTypes {
+		id := strings.Split(st, ":")[3]
+		fmt.Fprintf(&svcs, testServiceDescription, st, "urn:upnp-org:serviceId:"+id, id, id, id)
+	}
+	return fmt.Sprintf(testDeviceDescription, name, svcs.String())
+}
+
+// startDescriptionServer serves each description at /<key>.xml
+func startDescriptionServer(t *testing.T, descriptions map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		desc, ok := descriptions[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), ".xml")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(desc))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSearchMediaRenderersRequiredServices(t *testing.T) {
+	srv := startDescriptionServer(t, map[string]string{
+		"full":    testDescription("Full Renderer", services.AVTransport, services.RenderingControl, services.ConnectionManager),
+		"novol":   testDescription("No Volume Renderer", services.AVTransport, services.ConnectionManager),
+		"minimal": testDescription("Minimal Renderer", services.AVTransport),
+	})
+	fakeMulticastSearch(t,
+		avTransportResponse("uuid:test-device-0", srv.URL+"/full.xml"),
+		avTransportResponse("uuid:test-device-1", srv.URL+"/novol.xml"),
+		avTransportResponse("uuid:test-device-2", srv.URL+"/minimal.xml"),
+	)
+
+	tt := []struct {
+		name     string
+		required []services.Type
+		want     []string
+		excluded map[string][]services.Type
+	}{
+		{
+			`SearchMediaRenderers no required services`,
+			nil,
+			[]string{"Full Renderer", "No Volume Renderer", "Minimal Renderer"},
+			nil,
+		},
+		{
+			`SearchMediaRenderers RenderingControl`,
+			[]services.Type{services.RenderingControl},
+			[]string{"Full Renderer"},
+			map[string][]services.Type{
+				"No Volume Renderer": {services.RenderingControl},
+				"Minimal Renderer":   {services.RenderingControl},
+			},
+		},
+		{
+			`SearchMediaRenderers ConnectionManager and RenderingControl`,
+			[]services.Type{services.ConnectionManager, services.RenderingControl},
+			[]string{"Full Renderer"},
+			map[string][]services.Type{
+				"No Volume Renderer": {services.RenderingControl},
+				"Minimal Renderer":   {services.ConnectionManager, services.RenderingControl},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			devices, err := SearchMediaRenderers(ctx, 1, tc.required...)
+
+			var got []string
+			for _, d := range devices {
+				got = append(got, d.FriendlyName)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Fatalf("%s: got: %v, want: %v.", tc.name, got, tc.want)
+			}
+
+			var errs []error
+			if err != nil {
+				errs = err.(interface{ Unwrap() []error }).Unwrap()
+			}
+			excluded := map[string][]services.Type{}
+			for _, e := range errs {
+				var exclErr *ExcludedDeviceError
+				if errors.As(e, &exclErr) {
+					if !errors.Is(e, ErrUnsupportedService) {
+						t.Fatalf("%s: got error: %v, want: %v.", tc.name, e, ErrUnsupportedService)
+					}
+					excluded[exclErr.Device.FriendlyName] = exclErr.MissingServices
+				}
+			}
+			if fmt.Sprint(excluded) != fmt.Sprint(tc.excluded) {
+				t.Fatalf("%s: got excluded: %v, want: %v.", tc.name, excluded, tc.excluded)
+			}
+		})
+	}
+}
+
+func TestSupportsAction(t *testing.T) {
+	srv := startDescriptionServer(t, map[string]string{
+		"renderer": testDescription("Renderer", services.AVTransport, services.RenderingControl),
+		"AVTransport/scpd": `<?xml version="1.0"?>
+<scpd xmlns="urn:schemas-upnp-org:service-1-0">
+  <actionList>
+    <action>
+      <name>Play</name>
+      <argumentList>
+        <argument><name>InstanceID</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_InstanceID</relatedStateVariable></argument>
+        <argument><name>Speed</name><direction>in</direction><relatedStateVariable>TransportPlaySpeed</relatedStateVariable></argument>
+      </argumentList>
+    </action>
+    <action><name>Stop</name></action>
+  </actionList>
+  <serviceStateTable>
+    <stateVariable sendEvents="no"><name>TransportPlaySpeed</name><dataType>string</dataType><allowedValueList><allowedValue>1</allowedValue></allowedValueList></stateVariable>
+  </serviceStateTable>
+</scpd>`,
+	})
+
+	mr, err := mediaRendererFromDeviceURL(context.Background(), srv.URL+"/renderer.xml", "")
+	if err != nil {
+		t.Fatalf("Failed to load device due to %s", err.Error())
+	}
+
+	tt := []struct {
+		name        string
+		serviceType services.Type
+		action      string
+		want        bool
+	}{
+		{`SupportsAction Test #1`, services.AVTransport, "Play", true},
+		{`SupportsAction Test #2`, services.AVTransport, "Next", false},
+		{`SupportsAction Test #3`, services.RenderingControl, "SetVolume", false},
+		{`SupportsAction Test #4`, services.ConnectionManager, "GetProtocolInfo", false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if out := mr.SupportsAction(tc.serviceType, tc.action); out != tc.want {
+				t.Fatalf("%s: got: %t, want: %t.", tc.name, out, tc.want)
+			}
+		})
+	}
+
+	play := mr.ServiceDescription(services.AVTransport).Action("Play")
+	if len(play.Arguments) != 2 || play.Arguments[1].RelatedStateVariable != "TransportPlaySpeed" {
+		t.Fatalf("ServiceDescription: got Play arguments: %+v.", play.Arguments)
+	}
+}
+
+func TestRenderingControlClientDescription(t *testing.T) {
+	srv := startDescriptionServer(t, map[string]string{
+		"renderer": testDescription("Renderer", services.AVTransport, services.RenderingControl),
+		"RenderingControl/scpd": `<?xml version="1.0"?>
+<scpd xmlns="urn:schemas-upnp-org:service-1-0">
+  <serviceStateTable>
+    <stateVariable sendEvents="no"><name>A_ARG_TYPE_Channel</name><dataType>string</dataType><allowedValueList><allowedValue>Master</allowedValue><allowedValue>LF</allowedValue></allowedValueList></stateVariable>
+  </serviceStateTable>
+</scpd>`,
+	})
+
+	mr, err := mediaRendererFromDeviceURL(context.Background(), srv.URL+"/renderer.xml", "")
+	if err != nil {
+		t.Fatalf("Failed to load device due to %s", err.Error())
+	}
+	// the client must use the description fetched during discovery
+	srv.Close()
+
+	c, err := mr.RenderingControlClient()
+	if err != nil {
+		t.Fatalf("Failed to get RenderingControlClient due to %s", err.Error())
+	}
+	channels, err := c.SupportedChannels(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to call SupportedChannels due to %s", err.Error())
+	}
+	if got, want := fmt.Sprint(channels), "[Master LF]"; got != want {
+		t.Fatalf("SupportedChannels: got: %s, want: %s.", got, want)
+	}
+}
+
+func TestServiceVersion(t *testing.T) {
+	avTransport2 := services.WithVersion(services.AVTransport, 2)
+	renderingControl3 := services.WithVersion(services.RenderingControl, 3)
+	srv := startDescriptionServer(t, map[string]string{
+		"v1": testDescription("V1 Renderer", services.AVTransport, services.RenderingControl),
+		"v2": testDescription("V2 Renderer", avTransport2, renderingControl3),
+	})
+
+	tt := []struct {
+		name        string
+		device      string
+		serviceType services.Type
+		supported   bool
+		version     int
+	}{
+		{`ServiceVersion Test #1`, "v1", services.AVTransport, true, 1},
+		{`ServiceVersion Test #2`, "v1", avTransport2, false, 0},
+		{`ServiceVersion Test #3`, "v2", services.AVTransport, true, 2},
+		{`ServiceVersion Test #4`, "v2", avTransport2, true, 2},
+		{`ServiceVersion Test #5`, "v2", services.RenderingControl, true, 3},
+		{`ServiceVersion Test #6`, "v2", services.ConnectionManager, false, 0},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			mr, err := mediaRendererFromDeviceURL(context.Background(), srv.URL+"/"+tc.device+".xml", "")
+			if err != nil {
+				t.Fatalf("%s: Failed to load device due to %s", tc.name, err.Error())
+			}
+			if out := mr.SupportsService(tc.serviceType); out != tc.supported {
+				t.Fatalf("%s: got supported: %t, want: %t.", tc.name, out, tc.supported)
+			}
+			if out := mr.ServiceVersion(tc.serviceType); out != tc.version {
+				t.Fatalf("%s: got version: %d, want: %d.", tc.name, out, tc.version)
+			}
+		})
+	}
+
+	mr, err := mediaRendererFromDeviceURL(context.Background(), srv.URL+"/v2.xml", "")
+	if err != nil {
+		t.Fatalf("Failed to load device due to %s", err.Error())
+	}
+	avt, err := mr.AVTransportClient()
+	if err != nil {
+		t.Fatalf("Failed to create AVTransport client due to %s", err.Error())
+	}
+	if avt.ServiceType != avTransport2 {
+		t.Fatalf("AVTransportClient: got service type: %s, want: %s.", avt.ServiceType, avTransport2)
+	}
+}
+
+const testEmbeddedDeviceDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:Basic:1</deviceType>
+    <UDN>uuid:receiver</UDN>
+    <friendlyName>AV Receiver</friendlyName>
+    <deviceList>
+      <device>
+        <deviceType>urn:schemas-upnp-org:device:MediaServer:1</deviceType>
+        <UDN>uuid:server</UDN>
+        <friendlyName>Server</friendlyName>
+        <serviceList>%s</serviceList>
+      </device>
+      <device>
+        <deviceType>urn:schemas-upnp-org:device:MediaRenderer:1</deviceType>
+        <UDN>uuid:zone1</UDN>
+        <friendlyName>Zone 1</friendlyName>
+        <serviceList>%s</serviceList>
+      </device>
+      <device>
+        <deviceType>urn:schemas-upnp-org:device:Zones:1</deviceType>
+        <deviceList>
+          <device>
+            <deviceType>urn:schemas-upnp-org:device:MediaRenderer:2</deviceType>
+            <UDN>uuid:zone2</UDN>
+            <friendlyName>Zone 2</friendlyName>
+            <serviceList>%s</serviceList>
+          </device>
+        </deviceList>
+      </device>
+    </deviceList>
+  </device>
+</root>`
+
+func TestEmbeddedMediaRenderers(t *testing.T) {
+	serviceList := func(prefix string, serviceTypes ...services.Type) string {
+		var svcs strings.Builder
+		for _, st := range serviceTypes {
+			id := strings.Split(st, ":")[3]
+			fmt.Fprintf(&svcs, testServiceDescription, st, "urn:upnp-org:serviceId:"+id, prefix+id, prefix+id, prefix+id)
+		}
+		return svcs.String()
+	}
+	srv := startDescriptionServer(t, map[string]string{
+		"receiver": fmt.Sprintf(testEmbeddedDeviceDescription,
+			serviceList("server/", services.ConnectionManager),
+			serviceList("zone1/", services.AVTransport, services.RenderingControl),
+			serviceList("zone2/", services.AVTransport, services.ConnectionManager),
+		),
+	})
+
+	renderers, err := mediaRenderersFromDeviceURL(context.Background(), srv.URL+"/receiver.xml")
+	if err != nil {
+		t.Fatalf("Failed to load devices due to %s", err.Error())
+	}
+
+	tt := []struct {
+		name                  string
+		friendlyName          string
+		udn                   string
+		avTransportControlURL string
+		renderingControl      bool
+	}{
+		{`EmbeddedMediaRenderers Test #1`, "Zone 1", "uuid:zone1", srv.URL + "/zone1/AVTransport/control", true},
+		{`EmbeddedMediaRenderers Test #2`, "Zone 2", "uuid:zone2", srv.URL + "/zone2/AVTransport/control", false},
+	}
+	if len(renderers) != len(tt) {
+		t.Fatalf("EmbeddedMediaRenderers: got %d renderers, want: %d.", len(renderers), len(tt))
+	}
+
+	for i, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := renderers[i]
+			if mr.FriendlyName != tc.friendlyName || mr.UDN != tc.udn || mr.URL != srv.URL+"/receiver.xml" {
+				t.Fatalf("%s: got: %q %q %q.", tc.name, mr.FriendlyName, mr.UDN, mr.URL)
+			}
+			if mr.avTransportControlURL != tc.avTransportControlURL {
+				t.Fatalf("%s: got control URL: %s, want: %s.", tc.name, mr.avTransportControlURL, tc.avTransportControlURL)
+			}
+			if out := mr.SupportsService(services.RenderingControl); out != tc.renderingControl {
+				t.Fatalf("%s: got RenderingControl support: %t, want: %t.", tc.name, out, tc.renderingControl)
+			}
+		})
+	}
+
+	mr, err := mediaRendererFromDeviceURL(context.Background(), srv.URL+"/receiver.xml", "uuid:zone2")
+	if err != nil || mr.FriendlyName != "Zone 2" {
+		t.Fatalf("mediaRendererFromDeviceURL: got: %v, %v, want: Zone 2.", mr, err)
+	}
+}
+
+func TestDeviceIdentity(t *testing.T) {
+	srv := startDescriptionServer(t, map[string]string{
+		"renderer": `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:MediaRenderer:1</deviceType>
+    <UDN> uuid:4d696e69-444c-164e-9d41-b827eb54e939 </UDN>
+    <friendlyName>Living Room</friendlyName>
+    <manufacturer>ACME</manufacturer>
+    <manufacturerURL>http://acme.example/</manufacturerURL>
+    <modelDescription>Network speaker</modelDescription>
+    <modelName>Speaker</modelName>
+    <modelNumber>S-1</modelNumber>
+    <serialNumber>0123456789</serialNumber>
+    <presentationURL>/index.html</presentationURL>
+    <iconList>
+      <icon><mimetype>image/png</mimetype><width>48</width><height>48</height><depth>24</depth><url>icons/48.png</url></icon>
+      <icon><mimetype>image/png</mimetype><width>n/a</width><height></height><depth>24</depth><url>/icons/any.png</url></icon>
+    </iconList>
+    <serviceList>` + fmt.Sprintf(testServiceDescription, services.AVTransport, "urn:upnp-org:serviceId:AVTransport", "AVTransport", "AVTransport", "AVTransport") + `</serviceList>
+  </device>
+</root>`,
+	})
+
+	mr, err := mediaRendererFromDeviceURL(context.Background(), srv.URL+"/renderer.xml", "")
+	if err != nil {
+		t.Fatalf("Failed to load device due to %s", err.Error())
+	}
+
+	want := MediaRenderer{
+		URL:              srv.URL + "/renderer.xml",
+		FriendlyName:     "Living Room",
+		ModelName:        "Speaker",
+		UDN:              "uuid:4d696e69-444c-164e-9d41-b827eb54e939",
+		DeviceType:       "urn:schemas-upnp-org:device:MediaRenderer:1",
+		Manufacturer:     "ACME",
+		ManufacturerURL:  "http://acme.example/",
+		ModelNumber:      "S-1",
+		ModelDescription: "Network speaker",
+		SerialNumber:     "0123456789",
+		PresentationURL:  srv.URL + "/index.html",
+		Icons: []Icon{
+			{MimeType: "image/png", Width: 48, Height: 48, Depth: 24, URL: srv.URL + "/icons/48.png"},
+			{MimeType: "image/png", Depth: 24, URL: srv.URL + "/icons/any.png"},
+		},
+	}
+	got := MediaRenderer{
+		URL: mr.URL, FriendlyName: mr.FriendlyName, ModelName: mr.ModelName, UDN: mr.UDN, DeviceType: mr.DeviceType,
+		Manufacturer: mr.Manufacturer, ManufacturerURL: mr.ManufacturerURL, ModelNumber: mr.ModelNumber,
+		ModelDescription: mr.ModelDescription, SerialNumber: mr.SerialNumber, PresentationURL: mr.PresentationURL, Icons: mr.Icons,
+	}
+	if fmt.Sprintf("%+v", got) != fmt.Sprintf("%+v", want) {
+		t.Fatalf("DeviceIdentity: got: %+v, want: %+v.", got, want)
+	}
+}
+
+func TestServiceURL(t *testing.T) {
+	tt := []struct {
+		name string
+		base string
+		ref  string
+		want string
+	}{
+		{`serviceURL Test #1`, "http://192.168.1.20:49152/dd.xml", "/AVTransport/control", "http://192.168.1.20:49152/AVTransport/control"},
+		{`serviceURL Test #2`, "http://192.168.1.20:49152/desc/dd.xml", "AVTransport/control", "http://192.168.1.20:49152/AVTransport/control"},
+		{`serviceURL Test #3`, "http://[fd00::20]:49152/dd.xml", "/AVTransport/control", "http://[fd00::20]:49152/AVTransport/control"},
+		{`serviceURL Test #4`, "http://[fe80::1%25eth0]:49152/dd.xml", "/AVTransport/control", "http://[fe80::1%25eth0]:49152/AVTransport/control"},
+		{`serviceURL Test #5`, "http://[fe80::1%25eth0]:49152/dd.xml", "http://192.168.1.20:8080/control", "http://192.168.1.20:8080/control"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			base, err := url.Parse(tc.base)
+			if err != nil {
+				t.Fatalf("%s: Failed to parse base URL due to %s", tc.name, err.Error())
+			}
+			if got := serviceURL(base, tc.ref); got != tc.want {
+				t.Fatalf("%s: got: %v, want: %v.", tc.name, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadMediaRenderersTimeout(t *testing.T) {
+	srv := startDescriptionServer(t, map[string]string{
+		"tv": testDescriptionWithUDN("TV", "uuid:tv", services.AVTransport),
+	})
+	// a device that accepts the connection but never answers, like a sleeping TV
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(hung.Close)
+
+	locations := []deviceLocation{{url: hung.URL + "/dd.xml"}, {url: srv.URL + "/tv.xml"}, {url: hung.URL + "/dd2.xml"}}
+	start := time.Now()
+	devices, err := loadMediaRenderers(context.Background(), locations, SearchOptions{FetchWorkers: 2, FetchTimeout: 200 * time.Millisecond})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("loadMediaRenderers elapsed: got: %v, want: < 2s.", elapsed)
+	}
+
+	if len(devices) != 1 || devices[0].FriendlyName != "TV" {
+		t.Fatalf("loadMediaRenderers devices: got: %v, want: [TV].", devices)
+	}
+	errs := err.(interface{ Unwrap() []error }).Unwrap()
+	if len(errs) != 2 || !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(errs[0].Error(), hung.URL+"/dd.xml") {
+		t.Fatalf("loadMediaRenderers error: got: %v, want: 2 timeouts.", err)
+	}
+}
@@ -0,0 +1,404 @@
+package device
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/koron/go-ssdp"
+	"github.com/supersonic-app/go-upnpcast/services"
+)
+
+const (
+	defaultSearchInterval = 1 * time.Minute
+	defaultSearchWaitSec  = 3
+	defaultMaxAge         = 1800 // seconds, the UPnP recommended minimum
+	descriptionTimeout    = 10 * time.Second
+	defaultFetchWorkers   = 8
+	expiryCheckInterval   = 1 * time.Second
+	locationCheckInterval = 1 * time.Minute
+)
+
+// DiscoveryEventType is the type of a DiscoveryEvent
+type DiscoveryEventType int
+
+const (
+	// A new device has appeared on the LAN
+	DeviceAdded DiscoveryEventType = iota
+
+	// A device has left the LAN, either by announcing ssdp:byebye
+	// or by its advertisement expiring
+	DeviceRemoved
+
+	// A known device has changed its description or location
+	DeviceUpdated
+)
+
+func (t DiscoveryEventType) String() string {
+	switch t {
+	case DeviceAdded:
+		return "DeviceAdded"
+	case DeviceRemoved:
+		return "DeviceRemoved"
+	case DeviceUpdated:
+		return "DeviceUpdated"
+	}
+	return "Unknown"
+}
+
+// DiscoveryEvent is delivered by a Discoverer when the set of devices on the LAN changes
+type DiscoveryEvent struct {
+	Type DiscoveryEventType
+
+	// Unique device name of the device, which is stable across events
+	UDN string
+
+	// The device. For DeviceRemoved, this is the last known state of the device.
+	Device *MediaRenderer
+}
+
+// DiscovererOptions configures a Discoverer
+type DiscovererOptions struct {
+	// How often to send SSDP searches for devices.
+	// Default is 1 minute.
+	SearchInterval time.Duration
+
+	// How many seconds to wait for device responses to each SSDP search.
+	// Default is 3 seconds.
+	SearchWaitSec int
+
+	// Devices that do not support all of these services are not reported.
+	RequiredServices []services.Type
+
+	// IP versions to search with; see SearchOptions.
+	// SSDP notifications are only received over IPv4.
+	IPMode IPMode
+}
+
+// Discoverer continuously discovers MediaRenderer devices on the LAN,
+// by periodically searching and by listening for SSDP alive and byebye notifications.
+type Discoverer struct {
+	opts    DiscovererOptions
+	ctx     context.Context
+	cancel  context.CancelFunc
+	monitor notifyMonitor
+	events  chan DiscoveryEvent
+	msgs    chan discoveryMessage
+	fetched chan fetchResult
+	done    chan struct{}
+
+	closeOnce sync.Once
+
+	mu      sync.Mutex
+	devices map[string]*discoveredDevice
+}
+
+type discoveredDevice struct {
+	renderer  *MediaRenderer // nil until the description is first fetched
+	location  string
+	iface     *net.Interface // interface of the last search response, if any
+	localAddr net.IP
+	expires   time.Time
+	fetching  bool
+	excluded  bool // lacks a required service
+
+	// another location the device advertised, switched to if location stops working
+	candidate string
+	checked   time.Time // when location was last re-fetched to check it for a candidate
+}
+
+type discoveryMessage struct {
+	bye      bool
+	udn      string
+	location string
+	nt       string
+	maxAge   int
+
+	// set for search responses only
+	iface     *net.Interface
+	localAddr net.IP
+}
+
+// notifyMonitor listens for SSDP notifications
+type notifyMonitor interface {
+	Start() error
+	Close() error
+}
+
+// newMonitor returns the monitor of a Discoverer, replaced in tests
+var newMonitor = func(alive ssdp.AliveHandler, bye ssdp.ByeHandler) notifyMonitor {
+	return &ssdp.Monitor{Alive: alive, Bye: bye}
+}
+
+type fetchResult struct {
+	udn      string
+	location string
+	renderer *MediaRenderer
+	err      error
+}
+
+// NewDiscoverer starts discovering MediaRenderer devices in the background.
+// Discovery runs until Close is called or the context is cancelled.
+func NewDiscoverer(ctx context.Context, opts DiscovererOptions) (*Discoverer, error) {
+	if opts.SearchInterval <= 0 {
+		opts.SearchInterval = defaultSearchInterval
+	}
+	if opts.SearchWaitSec <= 0 {
+		opts.SearchWaitSec = defaultSearchWaitSec
+	}
+
+	d := &Discoverer{
+		opts:    opts,
+		events:  make(chan DiscoveryEvent, 16),
+		msgs:    make(chan discoveryMessage, 16),
+		fetched: make(chan fetchResult),
+		done:    make(chan struct{}),
+		devices: make(map[string]*discoveredDevice),
+	}
+	d.ctx, d.cancel = context.WithCancel(ctx)
+
+	d.monitor = newMonitor(
+		func(m *ssdp.AliveMessage) {
+			d.post(discoveryMessage{udn: udnFromUSN(m.USN), location: m.Location, nt: m.Type, maxAge: m.MaxAge()})
+		},
+		func(m *ssdp.ByeMessage) {
+			d.post(discoveryMessage{bye: true, udn: udnFromUSN(m.USN), nt: m.Type})
+		},
+	)
+	if err := d.monitor.Start(); err != nil {
+		d.cancel()
+		return nil, fmt.Errorf("SSDP monitor error: %w", err)
+	}
+
+	go d.searchLoop()
+	go d.run()
+	return d, nil
+}
+
+// Events returns the channel on which discovery events are delivered.
+// The channel is closed when the Discoverer is closed.
+// Discovery is paused while the channel is full, so it must be drained promptly.
+func (d *Discoverer) Events() <-chan DiscoveryEvent {
+	return d.events
+}
+
+// Devices returns the currently known devices
+func (d *Discoverer) Devices() []*MediaRenderer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	devices := make([]*MediaRenderer, 0, len(d.devices))
+	for _, dev := range d.devices {
+		if dev.renderer != nil && !dev.excluded {
+			devices = append(devices, dev.renderer)
+		}
+	}
+	return devices
+}
+
+// Close stops discovery and closes the events channel.
+func (d *Discoverer) Close() error {
+	d.closeOnce.Do(func() {
+		d.cancel()
+		<-d.done
+	})
+	return nil
+}
+
+func (d *Discoverer) post(msg discoveryMessage) {
+	select {
+	case d.msgs <- msg:
+	case <-d.ctx.Done():
+	}
+}
+
+func (d *Discoverer) searchLoop() {
+	ticker := time.NewTicker(d.opts.SearchInterval)
+	defer ticker.Stop()
+	for {
+		// search errors are transient (e.g. network down); retry on next tick
+		opts := SearchOptions{WaitSec: d.opts.SearchWaitSec, IPMode: d.opts.IPMode}
+		if responses, err := searchSSDPMediaRenderers(d.ctx, opts); err == nil {
+			for _, res := range preferResponses(responses, d.opts.IPMode) {
+				d.post(discoveryMessage{udn: udnFromUSN(res.USN), location: res.Location, nt: res.ST, maxAge: res.MaxAge,
+					iface: res.Interface, localAddr: res.LocalAddr})
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+func (d *Discoverer) run() {
+	defer close(d.done)
+	defer close(d.events)
+	// also when the context is cancelled without calling Close
+	defer d.monitor.Close()
+
+	ticker := time.NewTicker(expiryCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg := <-d.msgs:
+			if msg.bye {
+				d.remove(msg.udn)
+			} else {
+				d.alive(msg)
+			}
+		case res := <-d.fetched:
+			d.handleFetched(res)
+		case now := <-ticker.C:
+			d.expire(now)
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+func (d *Discoverer) alive(msg discoveryMessage) {
+	maxAge := msg.maxAge
+	if maxAge <= 0 {
+		maxAge = defaultMaxAge
+	}
+	expires := time.Now().Add(time.Duration(maxAge) * time.Second)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dev, ok := d.devices[msg.udn]
+	if !ok {
+		// only start tracking devices that advertise a renderer or the AVTransport service,
+		// but any advertisement of a tracked device keeps it alive
+		if !isMediaRendererTarget(msg.nt) || msg.location == "" {
+			return
+		}
+		dev = &discoveredDevice{location: msg.location, iface: msg.iface, localAddr: msg.localAddr}
+		d.devices[msg.udn] = dev
+		d.fetch(msg.udn, dev)
+	} else if isMediaRendererTarget(msg.nt) && msg.location != "" && msg.location != dev.location {
+		switch rank, current := d.opts.IPMode.rank(msg.location), d.opts.IPMode.rank(dev.location); {
+		case rank < current:
+			dev.location, dev.candidate = msg.location, ""
+			d.fetch(msg.udn, dev)
+		case rank == current:
+			// devices may advertise several locations, e.g. one per interface, so only
+			// switch if the current one has stopped working, e.g. after a restart on another port
+			dev.candidate = msg.location
+			if time.Since(dev.checked) >= locationCheckInterval {
+				dev.checked = time.Now()
+				d.fetch(msg.udn, dev)
+			}
+		}
+	}
+	if msg.iface != nil {
+		dev.iface, dev.localAddr = msg.iface, msg.localAddr
+	}
+	dev.expires = expires
+}
+
+// must be called with d.mu held
+func (d *Discoverer) fetch(udn string, dev *discoveredDevice) {
+	if dev.fetching {
+		// location is re-checked when the pending fetch completes
+		return
+	}
+	dev.fetching = true
+	location, iface, localAddr := dev.location, dev.iface, dev.localAddr
+	go func() {
+		ctx, cancel := context.WithTimeout(d.ctx, descriptionTimeout)
+		defer cancel()
+		mr, err := mediaRendererFromDeviceURL(ctx, location, udn)
+		if err == nil {
+			mr.Interface, mr.LocalAddr = iface, localAddr
+		}
+		select {
+		case d.fetched <- fetchResult{udn: udn, location: location, renderer: mr, err: err}:
+		case <-d.ctx.Done():
+		}
+	}()
+}
+
+func (d *Discoverer) handleFetched(res fetchResult) {
+	d.mu.Lock()
+	dev, ok := d.devices[res.udn]
+	if !ok {
+		// removed while fetching
+		d.mu.Unlock()
+		return
+	}
+	dev.fetching = false
+	if dev.location != res.location {
+		// location changed while fetching
+		d.fetch(res.udn, dev)
+		d.mu.Unlock()
+		return
+	}
+	if res.err != nil {
+		if dev.candidate != "" && dev.candidate != res.location {
+			dev.location, dev.candidate = dev.candidate, ""
+			d.fetch(res.udn, dev)
+			d.mu.Unlock()
+			return
+		}
+		if dev.renderer == nil {
+			// forget the device so it is retried on its next advertisement
+			delete(d.devices, res.udn)
+		}
+		d.mu.Unlock()
+		return
+	}
+
+	prev, wasExcluded := dev.renderer, dev.excluded
+	dev.renderer = res.renderer
+	dev.excluded = len(res.renderer.missingServices(d.opts.RequiredServices)) > 0
+	d.mu.Unlock()
+
+	switch {
+	case dev.excluded && prev != nil && !wasExcluded:
+		d.emit(DiscoveryEvent{Type: DeviceRemoved, UDN: res.udn, Device: prev})
+	case dev.excluded:
+	case prev == nil || wasExcluded:
+		d.emit(DiscoveryEvent{Type: DeviceAdded, UDN: res.udn, Device: res.renderer})
+	case !reflect.DeepEqual(prev, res.renderer):
+		d.emit(DiscoveryEvent{Type: DeviceUpdated, UDN: res.udn, Device: res.renderer})
+	}
+}
+
+func (d *Discoverer) remove(udn string) {
+	d.mu.Lock()
+	dev, ok := d.devices[udn]
+	delete(d.devices, udn)
+	d.mu.Unlock()
+
+	if ok && dev.renderer != nil && !dev.excluded {
+		d.emit(DiscoveryEvent{Type: DeviceRemoved, UDN: udn, Device: dev.renderer})
+	}
+}
+
+func (d *Discoverer) expire(now time.Time) {
+	var expired []string
+	d.mu.Lock()
+	for udn, dev := range d.devices {
+		if now.After(dev.expires) {
+			expired = append(expired, udn)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, udn := range expired {
+		d.remove(udn)
+	}
+}
+
+func (d *Discoverer) emit(e DiscoveryEvent) {
+	select {
+	case d.events <- e:
+	case <-d.ctx.Done():
+	}
+}
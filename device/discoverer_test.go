@@ -0,0 +1,167 @@
+package device
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/koron/go-ssdp"
+	"github.com/supersonic-app/go-upnpcast/services"
+)
+
+// fakeMonitor is a notifyMonitor whose notifications are sent by the test
+type fakeMonitor struct {
+	alive ssdp.AliveHandler
+	bye   ssdp.ByeHandler
+}
+
+func (m *fakeMonitor) Start() error { return nil }
+func (m *fakeMonitor) Close() error { return nil }
+
+// notify calls the monitor's handler for the nts with a notification of the AVTransport service
+func (m *fakeMonitor) notify(nts, usn, location string) {
+	if nts == "ssdp:byebye" {
+		m.bye(&ssdp.ByeMessage{Type: services.AVTransport, USN: usn})
+		return
+	}
+	m.alive(&ssdp.AliveMessage{Type: services.AVTransport, USN: usn, Location: location})
+}
+
+// startFakeMonitor replaces the SSDP monitor of Discoverers created during the test
+func startFakeMonitor(t *testing.T) *fakeMonitor {
+	t.Helper()
+	m := &fakeMonitor{}
+	orig := newMonitor
+	t.Cleanup(func() { newMonitor = orig })
+	newMonitor = func(alive ssdp.AliveHandler, bye ssdp.ByeHandler) notifyMonitor {
+		m.alive, m.bye = alive, bye
+		return m
+	}
+	return m
+}
+
+func waitDiscoveryEvent(t *testing.T, d *Discoverer, udn string) DiscoveryEvent {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e, ok := <-d.Events():
+			if !ok {
+				t.Fatalf("events channel closed")
+			}
+			if e.UDN == udn {
+				return e
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for discovery event for %s", udn)
+		}
+	}
+}
+
+func TestDiscoverer(t *testing.T) {
+	srv := startDescriptionServer(t, map[string]string{
+		"renamed": testDescription("Renamed Renderer", services.AVTransport, services.RenderingControl),
+		"notify":  testDescription("Notify Renderer", services.AVTransport, services.RenderingControl),
+	})
+	// the first location of the device, which stops working
+	oldSrv := startDescriptionServer(t, map[string]string{
+		"full": testDescription("Full Renderer", services.AVTransport, services.RenderingControl),
+	})
+	fakeMulticastSearch(t, avTransportResponse("uuid:test-device-0", oldSrv.URL+"/full.xml"))
+	monitor := startFakeMonitor(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	d, err := NewDiscoverer(ctx, DiscovererOptions{SearchWaitSec: 1})
+	if err != nil {
+		t.Fatalf("Failed to start discovery due to %s", err.Error())
+	}
+	defer d.Close()
+
+	tt := []struct {
+		name     string
+		nts      string
+		usn      string
+		location string
+		wantType DiscoveryEventType
+		wantName string
+	}{
+		{
+			`Discoverer search response`,
+			"",
+			"uuid:test-device-0",
+			"",
+			DeviceAdded,
+			"Full Renderer",
+		},
+		{
+			`Discoverer alive new location`,
+			"ssdp:alive",
+			"uuid:test-device-0::" + services.AVTransport,
+			srv.URL + "/renamed.xml",
+			DeviceUpdated,
+			"Renamed Renderer",
+		},
+		{
+			`Discoverer alive new device`,
+			"ssdp:alive",
+			"uuid:test-notify-device::" + services.AVTransport,
+			srv.URL + "/notify.xml",
+			DeviceAdded,
+			"Notify Renderer",
+		},
+		{
+			`Discoverer byebye`,
+			"ssdp:byebye",
+			"uuid:test-device-0::" + services.AVTransport,
+			"",
+			DeviceRemoved,
+			"Renamed Renderer",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.name == `Discoverer alive new location` {
+				oldSrv.Close()
+			}
+			if tc.nts != "" {
+				monitor.notify(tc.nts, tc.usn, tc.location)
+			}
+			e := waitDiscoveryEvent(t, d, udnFromUSN(tc.usn))
+			got := fmt.Sprintf("%s %s", e.Type, e.Device.FriendlyName)
+			want := fmt.Sprintf("%s %s", tc.wantType, tc.wantName)
+			if got != want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, got, want)
+			}
+		})
+	}
+
+	var names []string
+	for _, dev := range d.Devices() {
+		names = append(names, dev.FriendlyName)
+	}
+	if fmt.Sprint(names) != "[Notify Renderer]" {
+		t.Fatalf("Discoverer Devices: got: %v, want: [Notify Renderer].", names)
+	}
+
+	// a working device is not moved to another location of the same IP version
+	monitor.notify("ssdp:alive", "uuid:test-notify-device::"+services.AVTransport, srv.URL+"/renamed.xml")
+	select {
+	case e := <-d.Events():
+		t.Fatalf("Discoverer alive other location: got event: %s %s, want: none.", e.Type, e.Device.FriendlyName)
+	case <-time.After(500 * time.Millisecond):
+	}
+	d.mu.Lock()
+	location := d.devices["uuid:test-notify-device"].location
+	d.mu.Unlock()
+	if location != srv.URL+"/notify.xml" {
+		t.Fatalf("Discoverer alive other location: got location: %s, want: %s.", location, srv.URL+"/notify.xml")
+	}
+
+	d.Close()
+	if _, ok := <-d.Events(); ok {
+		t.Fatalf("Discoverer Close: events channel not closed")
+	}
+}
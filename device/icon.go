@@ -0,0 +1,112 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var ErrNoIcon = errors.New("the device does not advertise any icons")
+
+// Icon is an icon advertised in the device description
+type Icon struct {
+	// MIME type of the image, e.g. "image/png"
+	MimeType string
+
+	// Size in pixels, or 0 if not specified
+	Width  int
+	Height int
+
+	// Color depth in bits per pixel, or 0 if not specified
+	Depth int
+
+	// Absolute URL of the image
+	URL string
+}
+
+// BestIcon returns the device's icon that best matches the requested size and MIME type,
+// or nil if the device has no icons. It prefers icons of the MIME type, if not empty,
+// and then the smallest icon at least size pixels wide and high, or else the largest icon.
+func (m *MediaRenderer) BestIcon(size int, mimeType string) *Icon {
+	candidates := m.Icons
+	if mimeType != "" {
+		var matching []Icon
+		for _, icon := range m.Icons {
+			if strings.EqualFold(icon.MimeType, mimeType) {
+				matching = append(matching, icon)
+			}
+		}
+		if len(matching) > 0 {
+			candidates = matching
+		}
+	}
+
+	var best *Icon
+	for i := range candidates {
+		icon := &candidates[i]
+		if best == nil || betterIcon(icon, best, size) {
+			best = icon
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	icon := *best
+	return &icon
+}
+
+// betterIcon returns whether a is a better match than b for the requested size
+func betterIcon(a, b *Icon, size int) bool {
+	aSize, bSize := min(a.Width, a.Height), min(b.Width, b.Height)
+	aFits, bFits := aSize >= size, bSize >= size
+	switch {
+	case aFits && bFits:
+		if aSize != bSize {
+			return aSize < bSize
+		}
+		return a.Depth > b.Depth
+	case aFits != bFits:
+		return aFits
+	}
+	if aSize != bSize {
+		return aSize > bSize
+	}
+	return a.Depth > b.Depth
+}
+
+// FetchIcon downloads the icon returned by BestIcon for the given size and MIME type.
+// Returns the image data and the icon it was downloaded from,
+// or ErrNoIcon if the device has no icons.
+func (m *MediaRenderer) FetchIcon(ctx context.Context, size int, mimeType string) ([]byte, *Icon, error) {
+	icon := m.BestIcon(size, mimeType)
+	if icon == nil {
+		return nil, nil, ErrNoIcon
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, icon.URL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("setup GET icon error: %w", err)
+	}
+	req.Header.Set("Connection", "close")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("do GET icon error: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("GET icon HTTP error: %s", res.Status)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read icon error: %w", err)
+	}
+	return data, icon, nil
+}
@@ -0,0 +1,38 @@
+package device
+
+import "testing"
+
+func TestBestIcon(t *testing.T) {
+	mr := &MediaRenderer{Icons: []Icon{
+		{MimeType: "image/png", Width: 48, Height: 48, Depth: 24, URL: "http://dev/48.png"},
+		{MimeType: "image/png", Width: 120, Height: 120, Depth: 24, URL: "http://dev/120.png"},
+		{MimeType: "image/jpeg", Width: 120, Height: 120, Depth: 24, URL: "http://dev/120.jpg"},
+		{MimeType: "image/jpeg", Width: 256, Height: 256, Depth: 24, URL: "http://dev/256.jpg"},
+	}}
+
+	tt := []struct {
+		name     string
+		size     int
+		mimeType string
+		want     string
+	}{
+		{`BestIcon Test #1`, 100, "image/png", "http://dev/120.png"},
+		{`BestIcon Test #2`, 32, "image/png", "http://dev/48.png"},
+		{`BestIcon Test #3`, 512, "image/png", "http://dev/120.png"},
+		{`BestIcon Test #4`, 200, "", "http://dev/256.jpg"},
+		{`BestIcon Test #5`, 100, "image/gif", "http://dev/120.png"},
+		{`BestIcon Test #6`, 0, "IMAGE/JPEG", "http://dev/120.jpg"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if out := mr.BestIcon(tc.size, tc.mimeType); out == nil || out.URL != tc.want {
+				t.Fatalf("%s: got: %+v, want: %s.", tc.name, out, tc.want)
+			}
+		})
+	}
+
+	if out := (&MediaRenderer{}).BestIcon(48, ""); out != nil {
+		t.Fatalf("BestIcon no icons: got: %+v, want: nil.", out)
+	}
+}
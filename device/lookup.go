@@ -0,0 +1,173 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/supersonic-app/go-upnpcast/internal/msearch"
+	"github.com/supersonic-app/go-upnpcast/services"
+)
+
+var ErrDeviceNotFound = errors.New("the device could not be found")
+
+// NewMediaRendererFromURL loads the MediaRenderer described at descriptionURL,
+// e.g. a URL previously saved from MediaRenderer.URL, without an SSDP search.
+// If the description contains several renderers, the first one is returned.
+func NewMediaRendererFromURL(ctx context.Context, descriptionURL string) (*MediaRenderer, error) {
+	return mediaRendererFromDeviceURL(ctx, descriptionURL, "")
+}
+
+// ProbeMediaRenderers searches for MediaRenderer devices at the given host, e.g. "192.168.1.20",
+// by sending the SSDP search directly to the host rather than to the multicast group.
+// This finds devices on networks where multicast is blocked, as long as their address is known.
+// The host may include a port; the default is the SSDP port 1900.
+// See SearchMediaRenderers for waitSec, requiredServices and the returned error.
+func ProbeMediaRenderers(ctx context.Context, host string, waitSec int, requiredServices ...services.Type) ([]*MediaRenderer, error) {
+	opts := SearchOptions{WaitSec: waitSec, RequiredServices: requiredServices}
+	var responses []msearch.Response
+	err := unicastSearch(ctx, host, opts.request(), func(res msearch.Response) { responses = append(responses, res) })
+	if err != nil {
+		return nil, fmt.Errorf("SSDP probe error: %w", err)
+	}
+
+	return loadMediaRenderers(ctx, responseLocations(filterMediaRenderers(responses, opts.request().STs)), opts)
+}
+
+// unicastSearch sends unicast M-SEARCH requests, replaced in tests
+var unicastSearch = msearch.UnicastFunc
+
+// ResolveMediaRenderer finds the MediaRenderer with the given unique device name,
+// e.g. one saved from MediaRenderer.UDN to remember the user's choice across restarts.
+// knownURLs are description URLs where the device was previously found, if any;
+// these are tried first, then their hosts are probed in case the device changed its port,
+// and finally the LAN is searched. Searches wait up to waitSec seconds for responses,
+// and end as soon as the device is found.
+// Returns ErrDeviceNotFound if no device with the UDN responds.
+func ResolveMediaRenderer(ctx context.Context, udn string, waitSec int, knownURLs ...string) (*MediaRenderer, error) {
+	for _, u := range knownURLs {
+		if mr := findMediaRenderer(ctx, udn, []deviceLocation{{url: u}}); mr != nil {
+			return mr, nil
+		}
+	}
+
+	opts := SearchOptions{WaitSec: waitSec}
+	if opts.WaitSec <= 0 {
+		opts.WaitSec = defaultSearchWaitSec
+	}
+	var hosts listSet
+	for _, u := range knownURLs {
+		if parsed, err := url.Parse(u); err == nil && parsed.Hostname() != "" {
+			hosts.add(parsed.Hostname())
+		}
+	}
+	if len(hosts) > 0 {
+		mr, _ := searchMediaRenderer(ctx, udn, func(ctx context.Context, fn func(msearch.Response)) error {
+			return probeHosts(ctx, hosts, opts.request(), fn)
+		})
+		if mr != nil {
+			return mr, nil
+		}
+	}
+
+	targets, err := searchTargets(opts)
+	if err != nil {
+		return nil, fmt.Errorf("SSDP search error: %w", err)
+	}
+	mr, err := searchMediaRenderer(ctx, udn, func(ctx context.Context, fn func(msearch.Response)) error {
+		return searchSSDP(ctx, targets, opts.request(), fn)
+	})
+	if mr != nil {
+		return mr, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: %s", ErrDeviceNotFound, udn)
+}
+
+// searchMediaRenderer runs the search and loads the renderer with the given UDN from
+// the locations it answers from, as they arrive. The search is cancelled once
+// the renderer is loaded. Returns nil if it is not found.
+func searchMediaRenderer(ctx context.Context, udn string, search func(context.Context, func(msearch.Response)) error) (*MediaRenderer, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		found     *MediaRenderer // guarded by mu
+		locations listSet        // only used by the search callback
+	)
+	err := search(ctx, func(res msearch.Response) {
+		if udnFromUSN(res.USN) != udn || res.Location == "" || !locations.add(res.Location) {
+			return
+		}
+		l := deviceLocation{url: res.Location, iface: res.Interface, localAddr: res.LocalAddr}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if mr := findMediaRenderer(ctx, udn, []deviceLocation{l}); mr != nil {
+				mu.Lock()
+				if found == nil {
+					found = mr
+				}
+				mu.Unlock()
+				cancel()
+			}
+		}()
+	})
+	wg.Wait()
+
+	if found != nil {
+		return found, nil
+	}
+	return nil, err
+}
+
+// probeHosts sends unicast searches to all hosts in parallel and calls fn with each response,
+// one at a time, as soon as it is received. Fails only if the search fails on every host.
+func probeHosts(ctx context.Context, hosts []string, req msearch.Request, fn func(msearch.Response)) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, host := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := unicastSearch(ctx, host, req, func(res msearch.Response) {
+				mu.Lock()
+				defer mu.Unlock()
+				fn(res)
+			})
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, fmt.Errorf("%s: %w", host, err))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) == len(hosts) {
+		return fmt.Errorf("SSDP probe error: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// findMediaRenderer returns the renderer with the given UDN described at one of the locations, or nil
+func findMediaRenderer(ctx context.Context, udn string, deviceLocations []deviceLocation) *MediaRenderer {
+	for _, l := range deviceLocations {
+		renderers, _ := loadLocation(ctx, l, descriptionTimeout)
+		for _, mr := range renderers {
+			if mr.UDN == udn {
+				return mr
+			}
+		}
+	}
+	return nil
+}
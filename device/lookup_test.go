@@ -0,0 +1,104 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supersonic-app/go-upnpcast/internal/msearch"
+	"github.com/supersonic-app/go-upnpcast/services"
+)
+
+// testDescriptionWithUDN is like testDescription, with the given UDN
+func testDescriptionWithUDN(name, udn string, serviceTypes ...services.Type) string {
+	return strings.Replace(testDescription(name, serviceTypes...), "<friendlyName>", "<UDN>"+udn+"</UDN><friendlyName>", 1)
+}
+
+func TestProbeMediaRenderers(t *testing.T) {
+	srv := startDescriptionServer(t, map[string]string{
+		"tv": testDescriptionWithUDN("TV", "uuid:tv", services.AVTransport, services.RenderingControl),
+	})
+	fakeUnicastSearch(t, avTransportResponse("uuid:tv", srv.URL+"/tv.xml"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	devices, err := ProbeMediaRenderers(ctx, "192.0.2.20", 1, services.RenderingControl)
+	if err != nil {
+		t.Fatalf("Failed to probe due to %s", err.Error())
+	}
+	if len(devices) != 1 || devices[0].UDN != "uuid:tv" || devices[0].FriendlyName != "TV" {
+		t.Fatalf("ProbeMediaRenderers: got: %+v, want: TV.", devices)
+	}
+}
+
+func TestResolveMediaRenderer(t *testing.T) {
+	srv := startDescriptionServer(t, map[string]string{
+		"tv":    testDescriptionWithUDN("TV", "uuid:tv", services.AVTransport),
+		"other": testDescriptionWithUDN("Other", "uuid:other", services.AVTransport),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	mr, err := ResolveMediaRenderer(ctx, "uuid:tv", 1, srv.URL+"/other.xml", srv.URL+"/tv.xml")
+	if err != nil {
+		t.Fatalf("Failed to resolve due to %s", err.Error())
+	}
+	if mr.FriendlyName != "TV" {
+		t.Fatalf("ResolveMediaRenderer: got: %s, want: TV.", mr.FriendlyName)
+	}
+
+	mr, err = NewMediaRendererFromURL(ctx, srv.URL+"/other.xml")
+	if err != nil || mr.UDN != "uuid:other" {
+		t.Fatalf("NewMediaRendererFromURL: got: %v, %v, want: uuid:other.", mr, err)
+	}
+
+	fakeUnicastSearch(t)
+	fakeMulticastSearch(t)
+	_, err = ResolveMediaRenderer(ctx, "uuid:missing", 1, srv.URL+"/other.xml")
+	if !errors.Is(err, ErrDeviceNotFound) {
+		t.Fatalf("ResolveMediaRenderer missing: got error: %v, want: %v.", err, ErrDeviceNotFound)
+	}
+}
+
+func TestResolveMediaRendererMoved(t *testing.T) {
+	srv := startDescriptionServer(t, map[string]string{
+		"tv": testDescriptionWithUDN("TV", "uuid:tv", services.AVTransport),
+	})
+
+	tt := []struct {
+		name       string
+		unicast    []msearch.Response
+		multicast  []msearch.Response
+		wantBefore time.Duration
+	}{
+		{`ResolveMediaRenderer probe`, []msearch.Response{avTransportResponse("uuid:other", srv.URL+"/other.xml"), avTransportResponse("uuid:tv", srv.URL+"/tv.xml")}, nil, time.Second},
+		{`ResolveMediaRenderer search`, nil, []msearch.Response{avTransportResponse("uuid:tv", srv.URL+"/tv.xml")}, 4 * time.Second},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeUnicastSearch(t, tc.unicast...)
+			fakeMulticastSearch(t, tc.multicast...)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			start := time.Now()
+			mr, err := ResolveMediaRenderer(ctx, "uuid:tv", 3, srv.URL+"/old.xml")
+			if err != nil {
+				t.Fatalf("%s: Failed to resolve due to %s", tc.name, err.Error())
+			}
+			if mr.URL != srv.URL+"/tv.xml" {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, mr.URL, srv.URL+"/tv.xml")
+			}
+			// the probe waits for the full waitSec before the LAN is searched
+			if elapsed := time.Since(start); elapsed >= tc.wantBefore {
+				t.Fatalf("%s: got resolved after: %v, want: before %v.", tc.name, elapsed, tc.wantBefore)
+			}
+		})
+	}
+}
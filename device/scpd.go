@@ -0,0 +1,20 @@
+package device
+
+import "github.com/supersonic-app/go-upnpcast/internal/scpd"
+
+// ServiceDescription is a service's control protocol description (SCPD),
+// listing the actions and state variables it implements.
+// Use Action and StateVariable to look up entries by name.
+type ServiceDescription = scpd.Document
+
+// ServiceAction is an action listed in a ServiceDescription
+type ServiceAction = scpd.Action
+
+// ActionArgument is an input or output argument of a ServiceAction
+type ActionArgument = scpd.Argument
+
+// StateVariable is a state variable listed in a ServiceDescription
+type StateVariable = scpd.StateVariable
+
+// AllowedValueRange is the range of values allowed for a numeric StateVariable
+type AllowedValueRange = scpd.AllowedValueRange
@@ -0,0 +1,491 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/supersonic-app/go-upnpcast/internal/msearch"
+	"github.com/supersonic-app/go-upnpcast/services"
+)
+
+// SearchOptions configures SearchMediaRenderersWithOptions
+type SearchOptions struct {
+	// How many seconds to wait for device responses to the SSDP search.
+	// Default is 3 seconds.
+	WaitSec int
+
+	// Devices that do not support all of these services are excluded from the results.
+	RequiredServices []services.Type
+
+	// Network interfaces to search on, in parallel.
+	// Default is all interfaces that are up, support multicast and have an IPv4 address.
+	Interfaces []net.Interface
+
+	// Local addresses to search from, e.g. to choose between several addresses
+	// of an interface. If set, Interfaces is ignored.
+	LocalAddrs []net.IP
+
+	// IP versions to search with, and which to prefer when a device answers on both.
+	// Default is IPv4Only.
+	IPMode IPMode
+
+	// SSDP search targets, each sent in its own M-SEARCH request. Default is
+	// the MediaRenderer:1 device type and the AVTransport:1 service type,
+	// which devices implementing later versions also answer.
+	// For "ssdp:all", only the responses for those two types are kept.
+	SearchTargets []string
+
+	// Maximum seconds devices may wait before responding (the MX header).
+	// Default and maximum is WaitSec.
+	MX int
+
+	// How many times each M-SEARCH request is resent, to make up for lost packets.
+	// Default is 0, i.e. each request is sent once.
+	Retransmissions int
+
+	// Maximum number of device descriptions fetched in parallel.
+	// Default is 8.
+	FetchWorkers int
+
+	// How long to wait for each device's description, after which the device is
+	// left out of the results. Default is 10 seconds.
+	FetchTimeout time.Duration
+}
+
+func (o SearchOptions) fetchWorkers() int {
+	if o.FetchWorkers <= 0 {
+		return defaultFetchWorkers
+	}
+	return o.FetchWorkers
+}
+
+func (o SearchOptions) fetchTimeout() time.Duration {
+	if o.FetchTimeout <= 0 {
+		return descriptionTimeout
+	}
+	return o.FetchTimeout
+}
+
+// defaultSearchTargets are the SSDP search targets that renderers respond to
+var defaultSearchTargets = []string{mediaRendererDeviceType, services.AVTransport}
+
+// request returns the M-SEARCH request for the options
+func (o SearchOptions) request() msearch.Request {
+	sts := o.SearchTargets
+	if len(sts) == 0 {
+		sts = defaultSearchTargets
+	}
+	return msearch.Request{STs: sts, MX: o.MX, Retransmissions: o.Retransmissions, WaitSec: o.WaitSec}
+}
+
+// IPMode selects the IP versions used for SSDP searches
+type IPMode int
+
+const (
+	// Search over IPv4 only
+	IPv4Only IPMode = iota
+
+	// Search over IPv4 and IPv6, and use the IPv4 location of devices that answer on both
+	PreferIPv4
+
+	// Search over IPv4 and IPv6, and use the IPv6 location of devices that answer on both
+	PreferIPv6
+
+	// Search over IPv6 only
+	IPv6Only
+)
+
+func (m IPMode) ipv4() bool { return m != IPv6Only }
+func (m IPMode) ipv6() bool { return m != IPv4Only }
+
+// rank returns 0 if the host of the location has the preferred IP version, and 1 otherwise
+func (m IPMode) rank(location string) int {
+	u, err := url.Parse(location)
+	if err != nil {
+		return 1
+	}
+	ip := net.ParseIP(u.Hostname())
+	if ip == nil {
+		// host names are no worse than either version
+		return 0
+	}
+	if isIPv4 := ip.To4() != nil; isIPv4 == (m == PreferIPv4 || m == IPv4Only) {
+		return 0
+	}
+	return 1
+}
+
+// deviceLocation is the URL of a device description,
+// and the local interface and address the device was found from
+type deviceLocation struct {
+	url       string
+	iface     *net.Interface
+	localAddr net.IP
+}
+
+// searchTarget is a local interface and address to send a search from
+type searchTarget struct {
+	iface     *net.Interface
+	localAddr net.IP
+}
+
+// SearchMediaRenderersWithOptions is like SearchMediaRenderers,
+// with options to choose the network interfaces to search on.
+// If passing a context with deadline/expiration, it should be longer than WaitSec.
+func SearchMediaRenderersWithOptions(ctx context.Context, opts SearchOptions) ([]*MediaRenderer, error) {
+	responses, err := searchSSDPMediaRenderers(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadMediaRenderers(ctx, responseLocations(preferResponses(responses, opts.IPMode)), opts)
+}
+
+// SearchMediaRenderersStream is like SearchMediaRenderersWithOptions, but sends each renderer
+// on the returned channel as soon as it has answered the search and its description is loaded,
+// rather than after WaitSec seconds. The channel is closed when the search has ended and
+// all descriptions are loaded, or when the context is done; the caller must receive until then.
+// Devices that cannot be loaded or that lack one of the required services are left out.
+// If a device answers from several locations, the others are tried when loading one fails.
+// Devices that only answered over the IP version that is not preferred are sent after the search ends.
+// An error is only returned if the search cannot be started.
+func SearchMediaRenderersStream(ctx context.Context, opts SearchOptions) (<-chan *MediaRenderer, error) {
+	if opts.WaitSec <= 0 {
+		opts.WaitSec = defaultSearchWaitSec
+	}
+	targets, err := searchTargets(opts)
+	if err != nil {
+		return nil, fmt.Errorf("SSDP search error: %w", err)
+	}
+
+	ch := make(chan *MediaRenderer)
+	go func() {
+		defer close(ch)
+
+		var (
+			wg          sync.WaitGroup
+			mu          sync.Mutex
+			locations   listSet                          // of the responses, only used by the search callback
+			devices     = make(map[string]*streamDevice) // by UDN, guarded by mu
+			loaded      listSet                          // of the sent renderers, guarded by mu
+			searchEnded bool                             // guarded by mu
+			sem         = make(chan struct{}, opts.fetchWorkers())
+			req         = opts.request()
+		)
+
+		// send loads the location and sends its renderers,
+		// and returns false if the description could not be loaded
+		send := func(l deviceLocation) bool {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return false
+			}
+			renderers, err := loadLocation(ctx, l, opts.fetchTimeout())
+			<-sem
+
+			for _, mr := range renderers {
+				mu.Lock()
+				isNew := mr.UDN == "" || loaded.add(mr.UDN)
+				mu.Unlock()
+				if !isNew || len(mr.missingServices(opts.RequiredServices)) > 0 {
+					continue
+				}
+				select {
+				case ch <- mr:
+				case <-ctx.Done():
+					return true
+				}
+			}
+			return err == nil
+		}
+
+		// load starts loading the device from its next location, unless it is
+		// already loading or loaded. Must be called with mu held.
+		var load func(d *streamDevice)
+		load = func(d *streamDevice) {
+			if d.loading || d.loaded {
+				return
+			}
+			l, ok := d.next(opts.IPMode, searchEnded)
+			if !ok {
+				return
+			}
+			d.loading = true
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok := send(l)
+
+				mu.Lock()
+				defer mu.Unlock()
+				d.loading, d.loaded = false, ok
+				load(d)
+			}()
+		}
+
+		searchSSDP(ctx, targets, req, func(res msearch.Response) {
+			if res.Location == "" || len(filterMediaRenderers([]msearch.Response{res}, req.STs)) == 0 {
+				return
+			}
+			if !locations.add(res.Location) {
+				return
+			}
+
+			// devices may answer from several locations, e.g. on several interfaces
+			key := udnFromUSN(res.USN)
+			if key == "" {
+				key = res.Location
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			d := devices[key]
+			if d == nil {
+				d = &streamDevice{}
+				devices[key] = d
+			}
+			d.locations = append(d.locations, deviceLocation{url: res.Location, iface: res.Interface, localAddr: res.LocalAddr})
+			load(d)
+		})
+
+		mu.Lock()
+		searchEnded = true
+		for _, d := range devices {
+			load(d)
+		}
+		mu.Unlock()
+		wg.Wait()
+	}()
+	return ch, nil
+}
+
+// streamDevice is a device found by SearchMediaRenderersStream
+type streamDevice struct {
+	locations       []deviceLocation // not tried yet
+	loading, loaded bool
+}
+
+// next removes and returns the next location to load the device from, one of
+// the preferred IP version if any, or any location once the search has ended
+func (d *streamDevice) next(mode IPMode, searchEnded bool) (deviceLocation, bool) {
+	for i, l := range d.locations {
+		if mode.rank(l.url) == 0 {
+			d.locations = slices.Delete(d.locations, i, i+1)
+			return l, true
+		}
+	}
+	if !searchEnded || len(d.locations) == 0 {
+		return deviceLocation{}, false
+	}
+	l := d.locations[0]
+	d.locations = d.locations[1:]
+	return l, true
+}
+
+// Gets the SSDP search responses of all found renderers,
+// searching from all targets in parallel. Fails only if the search fails on every target.
+func searchSSDPMediaRenderers(ctx context.Context, opts SearchOptions) ([]msearch.Response, error) {
+	if opts.WaitSec <= 0 {
+		opts.WaitSec = defaultSearchWaitSec
+	}
+	targets, err := searchTargets(opts)
+	if err != nil {
+		return nil, fmt.Errorf("SSDP search error: %w", err)
+	}
+
+	var responses []msearch.Response
+	req := opts.request()
+	err = searchSSDP(ctx, targets, req, func(res msearch.Response) { responses = append(responses, res) })
+	if err != nil {
+		return nil, err
+	}
+	return filterMediaRenderers(responses, req.STs), nil
+}
+
+// multicastSearch sends the M-SEARCH requests of searchSSDP, replaced in tests
+var multicastSearch = msearch.MulticastFunc
+
+// searchSSDP searches from all targets in parallel and calls fn with each response,
+// one at a time, as soon as it is received. Fails only if the search fails on every target.
+func searchSSDP(ctx context.Context, targets []searchTarget, req msearch.Request, fn func(msearch.Response)) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := multicastSearch(ctx, t.iface, t.localAddr, req, func(res msearch.Response) {
+				mu.Lock()
+				defer mu.Unlock()
+				fn(res)
+			})
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, fmt.Errorf("%s: %w", t.localAddr, err))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) == len(targets) {
+		return fmt.Errorf("SSDP search error: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// searchTargets returns the local interfaces and addresses to search from
+func searchTargets(opts SearchOptions) ([]searchTarget, error) {
+	var targets []searchTarget
+	if len(opts.LocalAddrs) > 0 {
+		ifaces, _ := net.Interfaces()
+		for _, ip := range opts.LocalAddrs {
+			// the interface is only informational, the search is sent from the address
+			targets = append(targets, searchTarget{iface: interfaceWithAddr(ifaces, ip), localAddr: ip})
+		}
+		return targets, nil
+	}
+
+	ifaces := opts.Interfaces
+	if len(ifaces) == 0 {
+		var err error
+		if ifaces, err = net.Interfaces(); err != nil {
+			return nil, err
+		}
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+		if opts.IPMode.ipv4() {
+			if ip := interfaceIPv4(&iface); ip != nil {
+				targets = append(targets, searchTarget{iface: &iface, localAddr: ip})
+			}
+		}
+		if opts.IPMode.ipv6() {
+			if ip := interfaceIPv6(&iface); ip != nil {
+				targets = append(targets, searchTarget{iface: &iface, localAddr: ip})
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("no network interface with multicast support and an address of the requested IP version")
+	}
+	return targets, nil
+}
+
+// interfaceIPv4 returns the first IPv4 address of the interface, or nil if it has none
+func interfaceIPv4(iface *net.Interface) net.IP {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			if ip := ipNet.IP.To4(); ip != nil && !ip.IsUnspecified() {
+				return ip
+			}
+		}
+	}
+	return nil
+}
+
+// interfaceIPv6 returns the first IPv6 address of the interface, preferring
+// global and unique local addresses, which can also reach the site-local group,
+// over link-local ones. Returns nil if it has none.
+func interfaceIPv6(iface *net.Interface) net.IP {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil
+	}
+	var linkLocal net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.To4() != nil || ipNet.IP.IsUnspecified() || ipNet.IP.IsLoopback() {
+			continue
+		}
+		if !ipNet.IP.IsLinkLocalUnicast() {
+			return ipNet.IP
+		}
+		if linkLocal == nil {
+			linkLocal = ipNet.IP
+		}
+	}
+	return linkLocal
+}
+
+// interfaceWithAddr returns the interface that has the given address, or nil if there is none
+func interfaceWithAddr(ifaces []net.Interface, ip net.IP) *net.Interface {
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return &iface
+			}
+		}
+	}
+	return nil
+}
+
+// responseLocations returns the distinct description URLs of the SSDP responses,
+// with the interface each was first received on
+func responseLocations(responses []msearch.Response) []deviceLocation {
+	var urls listSet
+	var locations []deviceLocation
+	for _, res := range responses {
+		if res.Location != "" && urls.add(res.Location) {
+			locations = append(locations, deviceLocation{url: res.Location, iface: res.Interface, localAddr: res.LocalAddr})
+		}
+	}
+	return locations
+}
+
+// preferResponses drops the responses of devices that also answered over
+// the preferred IP version, so that each device is loaded from a single location
+func preferResponses(responses []msearch.Response, mode IPMode) []msearch.Response {
+	preferred := make(map[string]bool)
+	for _, res := range responses {
+		if mode.rank(res.Location) == 0 {
+			preferred[udnFromUSN(res.USN)] = true
+		}
+	}
+	return slices.DeleteFunc(responses, func(res msearch.Response) bool {
+		udn := udnFromUSN(res.USN)
+		return udn != "" && mode.rank(res.Location) > 0 && preferred[udn]
+	})
+}
+
+// filterMediaRenderers returns the SSDP responses for MediaRenderer devices and AVTransport services,
+// and the responses for any other search target in sts except msearch.All
+func filterMediaRenderers(responses []msearch.Response, sts []string) []msearch.Response {
+	return slices.DeleteFunc(responses, func(res msearch.Response) bool {
+		if isMediaRendererTarget(res.ST) {
+			return false
+		}
+		for _, st := range sts {
+			if st != msearch.All && (res.ST == st || services.Implements(res.ST, st)) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// isMediaRendererTarget returns true if an SSDP search or notification target is
+// the MediaRenderer device type or the AVTransport service type, in any version
+func isMediaRendererTarget(st string) bool {
+	// All DMRs we care about must support the AVTransport service
+	return services.Implements(st, services.AVTransport) || services.Implements(st, mediaRendererDeviceType)
+}
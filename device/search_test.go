@@ -0,0 +1,243 @@
+package device
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/supersonic-app/go-upnpcast/internal/msearch"
+	"github.com/supersonic-app/go-upnpcast/services"
+)
+
+// fakeSearch calls fn with each response, then waits WaitSec seconds like msearch searches,
+// and returns what they return
+func fakeSearch(ctx context.Context, req msearch.Request, responses []msearch.Response, fn func(msearch.Response)) error {
+	for _, res := range responses {
+		fn(res)
+	}
+	select {
+	case <-time.After(time.Duration(req.WaitSec) * time.Second):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fakeMulticastSearch replaces the multicast search for the test with one that answers with the responses
+func fakeMulticastSearch(t *testing.T, responses ...msearch.Response) {
+	t.Helper()
+	orig := multicastSearch
+	t.Cleanup(func() { multicastSearch = orig })
+	multicastSearch = func(ctx context.Context, ifi *net.Interface, localAddr net.IP, req msearch.Request, fn func(msearch.Response)) error {
+		return fakeSearch(ctx, req, responses, func(res msearch.Response) {
+			res.Interface, res.LocalAddr = ifi, localAddr
+			fn(res)
+		})
+	}
+}
+
+// fakeUnicastSearch replaces the unicast search for the test with one that answers with the responses
+func fakeUnicastSearch(t *testing.T, responses ...msearch.Response) {
+	t.Helper()
+	orig := unicastSearch
+	t.Cleanup(func() { unicastSearch = orig })
+	unicastSearch = func(ctx context.Context, host string, req msearch.Request, fn func(msearch.Response)) error {
+		return fakeSearch(ctx, req, responses, fn)
+	}
+}
+
+// avTransportResponse returns an SSDP response of the device for its AVTransport service
+func avTransportResponse(udn, location string) msearch.Response {
+	return msearch.Response{ST: services.AVTransport, USN: udn + "::" + services.AVTransport, Location: location}
+}
+
+func TestSearchMediaRenderersWithOptions(t *testing.T) {
+	srv := startDescriptionServer(t, map[string]string{
+		"tv": testDescriptionWithUDN("TV", "uuid:tv", services.AVTransport),
+	})
+	fakeMulticastSearch(t, avTransportResponse("uuid:tv", srv.URL+"/tv.xml"))
+
+	// searchTargets skips interfaces without multicast, which loopback may lack
+	ifaces, _ := net.Interfaces()
+	lo := interfaceWithAddr(ifaces, net.IPv4(127, 0, 0, 1))
+	if lo == nil {
+		t.Fatalf("SearchMediaRenderersWithOptions: no loopback interface.")
+	}
+	lo.Flags |= net.FlagUp | net.FlagMulticast
+
+	tt := []struct {
+		name string
+		opts SearchOptions
+	}{
+		{`SearchMediaRenderersWithOptions Interfaces`, SearchOptions{WaitSec: 1, Interfaces: []net.Interface{*lo}}},
+		{`SearchMediaRenderersWithOptions LocalAddrs`, SearchOptions{WaitSec: 1, LocalAddrs: []net.IP{net.IPv4(127, 0, 0, 1)}}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			devices, err := SearchMediaRenderersWithOptions(ctx, tc.opts)
+			if err != nil {
+				t.Fatalf("%s: Failed to search due to %s", tc.name, err.Error())
+			}
+			if len(devices) != 1 || devices[0].UDN != "uuid:tv" {
+				t.Fatalf("%s: got: %+v, want: uuid:tv.", tc.name, devices)
+			}
+			if found := devices[0]; found.Interface == nil || found.Interface.Name != lo.Name || !found.LocalAddr.Equal(net.IPv4(127, 0, 0, 1)) {
+				t.Fatalf("%s: got interface: %v %s, want: %s 127.0.0.1.", tc.name, found.Interface, found.LocalAddr, lo.Name)
+			}
+		})
+	}
+}
+
+func TestPreferResponses(t *testing.T) {
+	responses := []msearch.Response{
+		{USN: "uuid:tv::" + services.AVTransport, Location: "http://192.168.1.20:49152/dd.xml"},
+		{USN: "uuid:tv::" + services.AVTransport, Location: "http://[fd00::20]:49152/dd.xml"},
+		{USN: "uuid:radio::" + services.AVTransport, Location: "http://[fe80::1%25eth0]:8080/dd.xml"},
+	}
+
+	tt := []struct {
+		name string
+		mode IPMode
+		want []string
+	}{
+		{`preferResponses PreferIPv4`, PreferIPv4, []string{"http://192.168.1.20:49152/dd.xml", "http://[fe80::1%25eth0]:8080/dd.xml"}},
+		{`preferResponses PreferIPv6`, PreferIPv6, []string{"http://[fd00::20]:49152/dd.xml", "http://[fe80::1%25eth0]:8080/dd.xml"}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, res := range preferResponses(slices.Clone(responses), tc.mode) {
+				got = append(got, res.Location)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("%s: got: %v, want: %v.", tc.name, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFilterMediaRenderers(t *testing.T) {
+	responses := []msearch.Response{
+		{ST: "urn:schemas-upnp-org:device:MediaRenderer:1"},
+		{ST: "urn:schemas-upnp-org:service:AVTransport:2"},
+		{ST: "urn:schemas-upnp-org:device:MediaServer:1"},
+		{ST: "upnp:rootdevice"},
+	}
+
+	tt := []struct {
+		name string
+		sts  []string
+		want []string
+	}{
+		{`filterMediaRenderers default`, defaultSearchTargets, []string{"urn:schemas-upnp-org:device:MediaRenderer:1", "urn:schemas-upnp-org:service:AVTransport:2"}},
+		{`filterMediaRenderers ssdp:all`, []string{msearch.All}, []string{"urn:schemas-upnp-org:device:MediaRenderer:1", "urn:schemas-upnp-org:service:AVTransport:2"}},
+		{`filterMediaRenderers rootdevice`, []string{"upnp:rootdevice"}, []string{"urn:schemas-upnp-org:device:MediaRenderer:1", "urn:schemas-upnp-org:service:AVTransport:2", "upnp:rootdevice"}},
+	}
+
+	for _, tc := range tt {
+		var got []string
+		for _, res := range filterMediaRenderers(slices.Clone(responses), tc.sts) {
+			got = append(got, res.ST)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Fatalf("%s: got: %v, want: %v.", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSearchMediaRenderersStream(t *testing.T) {
+	srv := startDescriptionServer(t, map[string]string{
+		"tv":    testDescriptionWithUDN("TV", "uuid:stream-tv", services.AVTransport, services.RenderingControl),
+		"radio": testDescriptionWithUDN("Radio", "uuid:stream-radio", services.AVTransport),
+	})
+	fakeMulticastSearch(t,
+		avTransportResponse("uuid:stream-tv", srv.URL+"/tv.xml"),
+		avTransportResponse("uuid:stream-radio", srv.URL+"/radio.xml"),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	ch, err := SearchMediaRenderersStream(ctx, SearchOptions{WaitSec: 3, LocalAddrs: []net.IP{net.IPv4(127, 0, 0, 1)}, RequiredServices: []services.Type{services.RenderingControl}})
+	if err != nil {
+		t.Fatalf("Failed to search due to %s", err.Error())
+	}
+
+	var got []string
+	var firstAfter time.Duration
+	for mr := range ch {
+		if got == nil {
+			firstAfter = time.Since(start)
+		}
+		got = append(got, mr.FriendlyName)
+	}
+	if want := []string{"TV"}; !slices.Equal(got, want) {
+		t.Fatalf("SearchMediaRenderersStream: got: %v, want: %v.", got, want)
+	}
+	if firstAfter >= 3*time.Second {
+		t.Fatalf("SearchMediaRenderersStream first device: got: %v, want: before the search ends.", firstAfter)
+	}
+	if elapsed := time.Since(start); elapsed < 3*time.Second {
+		t.Fatalf("SearchMediaRenderersStream closed: got: %v, want: after the search ends.", elapsed)
+	}
+}
+
+func TestSearchMediaRenderersStreamLocations(t *testing.T) {
+	srv := startDescriptionServer(t, map[string]string{
+		"tv": testDescriptionWithUDN("TV", "uuid:stream-tv", services.AVTransport),
+	})
+
+	tt := []struct {
+		name          string
+		mode          IPMode
+		locations     []string
+		wantBeforeEnd bool
+	}{
+		{`SearchMediaRenderersStream fallback`, IPv4Only, []string{srv.URL + "/missing.xml", srv.URL + "/tv.xml"}, true},
+		{`SearchMediaRenderersStream duplicate`, IPv4Only, []string{srv.URL + "/tv.xml", srv.URL + "/tv.xml?again"}, true},
+		{`SearchMediaRenderersStream PreferIPv4`, PreferIPv4, []string{"http://[::1]:1/tv.xml", srv.URL + "/tv.xml"}, true},
+		{`SearchMediaRenderersStream PreferIPv6`, PreferIPv6, []string{srv.URL + "/tv.xml"}, false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var responses []msearch.Response
+			for _, l := range tc.locations {
+				responses = append(responses, avTransportResponse("uuid:stream-tv", l))
+			}
+			fakeMulticastSearch(t, responses...)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			start := time.Now()
+			ch, err := SearchMediaRenderersStream(ctx, SearchOptions{WaitSec: 1, IPMode: tc.mode, LocalAddrs: []net.IP{net.IPv4(127, 0, 0, 1)}})
+			if err != nil {
+				t.Fatalf("%s: Failed to search due to %s", tc.name, err.Error())
+			}
+
+			var got []string
+			var firstAfter time.Duration
+			for mr := range ch {
+				if got == nil {
+					firstAfter = time.Since(start)
+				}
+				got = append(got, mr.URL)
+			}
+			if want := []string{srv.URL + "/tv.xml"}; !slices.Equal(got, want) {
+				t.Fatalf("%s: got: %v, want: %v.", tc.name, got, want)
+			}
+			if beforeEnd := firstAfter < time.Second; beforeEnd != tc.wantBeforeEnd {
+				t.Fatalf("%s: got sent after: %v, want before the search ends: %v.", tc.name, firstAfter, tc.wantBeforeEnd)
+			}
+		})
+	}
+}
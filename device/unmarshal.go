@@ -7,31 +7,60 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/supersonic-app/go-upnpcast/internal/scpd"
 	"github.com/supersonic-app/go-upnpcast/services"
 )
 
+// mediaRendererDeviceType is the device type of version 1 MediaRenderer devices.
+// Later versions are also accepted.
+const mediaRendererDeviceType = "urn:schemas-upnp-org:device:MediaRenderer:1"
+
 type dmrSchema struct {
-	XMLName xml.Name `xml:"root"`
-	Device  struct {
-		XMLName      xml.Name `xml:"device"`
-		FriendlyName string   `xml:"friendlyName"`
-		ModelName    string   `xml:"modelName"`
-		ServiceList  struct {
-			XMLName  xml.Name `xml:"serviceList"`
-			Services []struct {
-				XMLName     xml.Name      `xml:"service"`
-				Type        services.Type `xml:"serviceType"`
-				ID          string        `xml:"serviceId"`
-				ControlURL  string        `xml:"controlURL"`
-				EventSubURL string        `xml:"eventSubURL"`
-			} `xml:"service"`
-		} `xml:"serviceList"`
-	} `xml:"device"`
+	XMLName xml.Name     `xml:"root"`
+	Device  deviceSchema `xml:"device"`
+}
+
+type deviceSchema struct {
+	DeviceType       string          `xml:"deviceType"`
+	UDN              string          `xml:"UDN"`
+	FriendlyName     string          `xml:"friendlyName"`
+	Manufacturer     string          `xml:"manufacturer"`
+	ManufacturerURL  string          `xml:"manufacturerURL"`
+	ModelDescription string          `xml:"modelDescription"`
+	ModelName        string          `xml:"modelName"`
+	ModelNumber      string          `xml:"modelNumber"`
+	SerialNumber     string          `xml:"serialNumber"`
+	PresentationURL  string          `xml:"presentationURL"`
+	Icons            []iconSchema    `xml:"iconList>icon"`
+	Services         []serviceSchema `xml:"serviceList>service"`
+	Devices          []deviceSchema  `xml:"deviceList>device"`
+}
+
+type iconSchema struct {
+	MimeType string `xml:"mimetype"`
+	Width    string `xml:"width"`
+	Height   string `xml:"height"`
+	Depth    string `xml:"depth"`
+	URL      string `xml:"url"`
+}
+
+type serviceSchema struct {
+	Type        services.Type `xml:"serviceType"`
+	ID          string        `xml:"serviceId"`
+	SCPDURL     string        `xml:"SCPDURL"`
+	ControlURL  string        `xml:"controlURL"`
+	EventSubURL string        `xml:"eventSubURL"`
 }
 
-func mediaRendererFromDeviceURL(ctx context.Context, dmrurl string) (*MediaRenderer, error) {
+// mediaRenderersFromDeviceURL fetches the device description at dmrurl and returns
+// a MediaRenderer for each renderer in its device tree, since the renderer may be
+// embedded in another device, e.g. an AV receiver. The returned renderers are valid
+// even if a non-nil error is also returned for renderers that could not be loaded.
+func mediaRenderersFromDeviceURL(ctx context.Context, dmrurl string) ([]*MediaRenderer, error) {
 	parsedURL, err := url.Parse(dmrurl)
 	if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
 		return nil, fmt.Errorf("device URL parse error: %w", err)
@@ -56,51 +85,203 @@ func mediaRendererFromDeviceURL(ctx context.Context, dmrurl string) (*MediaRende
 		return nil, fmt.Errorf("unmarshal device manifest error: %w", err)
 	}
 
+	var renderers []*MediaRenderer
+	var errs []error
+	for _, dev := range root.Device.mediaRenderers() {
+		mr, scpdURLs, err := mediaRendererFromDevice(parsedURL, dev)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		mr.URL = dmrurl
+		mr.serviceDescriptions = fetchServiceDescriptions(ctx, client, scpdURLs)
+		renderers = append(renderers, mr)
+	}
+	if len(renderers) == 0 && len(errs) == 0 {
+		return nil, errors.New("wrong DMR")
+	}
+
+	return renderers, errors.Join(errs...)
+}
+
+// mediaRendererFromDeviceURL returns the renderer described at dmrurl
+// with the given UDN, or the first renderer if none has the UDN.
+func mediaRendererFromDeviceURL(ctx context.Context, dmrurl, udn string) (*MediaRenderer, error) {
+	renderers, err := mediaRenderersFromDeviceURL(ctx, dmrurl)
+	if len(renderers) == 0 {
+		return nil, err
+	}
+	for _, mr := range renderers {
+		if mr.UDN == udn {
+			return mr, nil
+		}
+	}
+	return renderers[0], nil
+}
+
+// mediaRenderers returns the MediaRenderer devices in the device tree rooted at d
+// that offer the AVTransport service. If there are none, it returns any devices
+// that offer AVTransport, since some renderers advertise a different device type.
+func (d *deviceSchema) mediaRenderers() []*deviceSchema {
+	var renderers, others []*deviceSchema
+	d.walk(func(dev *deviceSchema) {
+		if !dev.hasService(services.AVTransport) {
+			return
+		}
+		if services.Implements(strings.TrimSpace(dev.DeviceType), mediaRendererDeviceType) {
+			renderers = append(renderers, dev)
+		} else {
+			others = append(others, dev)
+		}
+	})
+	if len(renderers) == 0 {
+		return others
+	}
+	return renderers
+}
+
+// walk calls fn for d and each of its embedded devices, depth-first
+func (d *deviceSchema) walk(fn func(*deviceSchema)) {
+	fn(d)
+	for i := range d.Devices {
+		d.Devices[i].walk(fn)
+	}
+}
+
+func (d *deviceSchema) hasService(serviceType services.Type) bool {
+	for _, s := range d.Services {
+		if services.Implements(s.Type, serviceType) {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaRendererFromDevice returns the renderer for the described device,
+// and the SCPD URL of each of its services
+func mediaRendererFromDevice(parsedURL *url.URL, dev *deviceSchema) (*MediaRenderer, map[services.Type]string, error) {
 	mr := &MediaRenderer{
-		URL:          dmrurl,
-		FriendlyName: root.Device.FriendlyName,
-		ModelName:    root.Device.ModelName,
-	}
-	for i := 0; i < len(root.Device.ServiceList.Services); i++ {
-		// normalize service URLs to start with leading /
-		service := root.Device.ServiceList.Services[i]
-		if !strings.HasPrefix(service.EventSubURL, "/") {
-			service.EventSubURL = "/" + service.EventSubURL
+		FriendlyName:     dev.FriendlyName,
+		ModelName:        dev.ModelName,
+		UDN:              strings.TrimSpace(dev.UDN),
+		DeviceType:       strings.TrimSpace(dev.DeviceType),
+		Manufacturer:     dev.Manufacturer,
+		ManufacturerURL:  strings.TrimSpace(dev.ManufacturerURL),
+		ModelNumber:      dev.ModelNumber,
+		ModelDescription: dev.ModelDescription,
+		SerialNumber:     dev.SerialNumber,
+		PresentationURL:  resolveURL(parsedURL, dev.PresentationURL),
+	}
+	for _, icon := range dev.Icons {
+		if u := resolveURL(parsedURL, icon.URL); u != "" {
+			mr.Icons = append(mr.Icons, Icon{
+				MimeType: strings.TrimSpace(icon.MimeType),
+				Width:    atoiOrZero(icon.Width),
+				Height:   atoiOrZero(icon.Height),
+				Depth:    atoiOrZero(icon.Depth),
+				URL:      u,
+			})
 		}
-		if !strings.HasPrefix(service.ControlURL, "/") {
-			service.ControlURL = "/" + service.ControlURL
+	}
+	scpdURLs := make(map[services.Type]string)
+	for _, service := range dev.Services {
+		// left empty if the description has none, rather than pointing at the host root
+		var scpdURL string
+		if strings.TrimSpace(service.SCPDURL) != "" {
+			scpdURL = serviceURL(parsedURL, service.SCPDURL)
+			if _, ok := scpdURLs[service.Type]; !ok {
+				scpdURLs[service.Type] = scpdURL
+			}
 		}
 
-		switch service.Type {
-		case services.AVTransport:
-			mr.avTransportControlURL = parsedURL.Scheme + "://" + parsedURL.Host + service.ControlURL
-			mr.avTransportEventSubURL = parsedURL.Scheme + "://" + parsedURL.Host + service.EventSubURL
+		mr.serviceTypes = append(mr.serviceTypes, service.Type)
+		switch {
+		case services.Implements(service.Type, services.AVTransport):
+			mr.avTransportControlURL = serviceURL(parsedURL, service.ControlURL)
+			mr.avTransportEventSubURL = serviceURL(parsedURL, service.EventSubURL)
 
 			if _, err := url.ParseRequestURI(mr.avTransportControlURL); err != nil {
-				return nil, fmt.Errorf("invalid AVTransportControlURL: %w", err)
+				return nil, nil, fmt.Errorf("invalid AVTransportControlURL: %w", err)
 			}
 
-			if _, err = url.ParseRequestURI(mr.avTransportEventSubURL); err != nil {
-				return nil, fmt.Errorf("invalid AVTransportEventSubURL: %w", err)
+			if _, err := url.ParseRequestURI(mr.avTransportEventSubURL); err != nil {
+				return nil, nil, fmt.Errorf("invalid AVTransportEventSubURL: %w", err)
 			}
-		case services.RenderingControl:
-			mr.renderingControlURL = parsedURL.Scheme + "://" + parsedURL.Host + service.ControlURL
+		case services.Implements(service.Type, services.RenderingControl):
+			mr.renderingControlURL = serviceURL(parsedURL, service.ControlURL)
+			mr.renderingControlSCPDURL = scpdURL
 
-			_, err = url.ParseRequestURI(mr.renderingControlURL)
-			if err != nil {
-				return nil, fmt.Errorf("invalid RenderingControlURL: %w", err)
+			if _, err := url.ParseRequestURI(mr.renderingControlURL); err != nil {
+				return nil, nil, fmt.Errorf("invalid RenderingControlURL: %w", err)
 			}
-		case services.ConnectionManager:
-			mr.connectionManagerURL = parsedURL.Scheme + "://" + parsedURL.Host + service.ControlURL
-			if err != nil {
-				return nil, fmt.Errorf("invalid ConnectionManagerURL: %w", err)
+		case services.Implements(service.Type, services.ConnectionManager):
+			mr.connectionManagerURL = serviceURL(parsedURL, service.ControlURL)
+
+			if _, err := url.ParseRequestURI(mr.connectionManagerURL); err != nil {
+				return nil, nil, fmt.Errorf("invalid ConnectionManagerURL: %w", err)
 			}
 		}
 	}
 
-	if mr.avTransportControlURL != "" {
-		return mr, nil
+	return mr, scpdURLs, nil
+}
+
+// serviceURL returns the absolute URL of a service's control, event or SCPD URL.
+// Relative URLs are resolved against the root of the description's host,
+// e.g. "control" is "/control", as many devices expect.
+// Returns "" if ref is invalid.
+func serviceURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if u, err := url.Parse(ref); err == nil && u.IsAbs() {
+		return u.String()
+	}
+	u, err := url.Parse("/" + strings.TrimPrefix(ref, "/"))
+	if err != nil {
+		return ""
+	}
+	// resolving, rather than concatenating, keeps IPv6 hosts and their zones escaped
+	return base.ResolveReference(u).String()
+}
+
+// resolveURL resolves a URL from the device description relative to the description's URL.
+// Returns "" if ref is empty or invalid.
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
 	}
+	return base.ResolveReference(u).String()
+}
+
+// atoiOrZero parses an integer description value, returning 0 if it is invalid
+func atoiOrZero(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
 
-	return nil, errors.New("wrong DMR")
+// fetchServiceDescriptions fetches the SCPD of each service, in parallel.
+// Services whose SCPD cannot be fetched are omitted; the device is still usable without them.
+func fetchServiceDescriptions(ctx context.Context, client *http.Client, scpdURLs map[services.Type]string) map[services.Type]*scpd.Document {
+	var (
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		descriptions = make(map[services.Type]*scpd.Document, len(scpdURLs))
+	)
+	for serviceType, scpdURL := range scpdURLs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if desc, err := scpd.Fetch(ctx, client, scpdURL); err == nil {
+				mu.Lock()
+				descriptions[serviceType] = desc
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return descriptions
 }
@@ -5,9 +5,7 @@ go 1.22.0
 require (
 	github.com/h2non/filetype v1.1.3
 	github.com/koron/go-ssdp v0.0.5
+	golang.org/x/net v0.33.0
 )
 
-require (
-	golang.org/x/net v0.33.0 // indirect
-	golang.org/x/sys v0.28.0 // indirect
-)
+require golang.org/x/sys v0.28.0 // indirect
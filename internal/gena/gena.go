@@ -0,0 +1,426 @@
+// Package gena implements UPnP GENA event subscriptions,
+// with a built-in HTTP server to receive event notifications.
+package gena
+
+import (
+	"cmp"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTimeout = 30 * time.Minute
+
+	// how long to wait before retrying a failed renewal
+	retryInterval = 10 * time.Second
+
+	unsubscribeTimeout = 5 * time.Second
+
+	// maximum number of notifications kept while a SUBSCRIBE is in flight
+	maxPendingEvents = 16
+)
+
+// Handler is called with the evented state variables of each event notification
+type Handler func(vars map[string]string)
+
+// Subscription is an active event subscription to a UPnP service
+type Subscription struct {
+	httpClient  *http.Client
+	eventSubURL string
+	timeout     time.Duration
+	handler     Handler
+
+	server      *http.Server
+	callbackURL string
+
+	// held while delivering events, so that the handler is called in order
+	deliverMu sync.Mutex
+
+	mu          sync.Mutex
+	sid         string
+	expires     time.Duration
+	subscribing bool           // a SUBSCRIBE for a new SID is in flight
+	pending     []pendingEvent // received while subscribing
+	nextSeq     uint32         // SEQ of the next expected event
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+	closeErr error
+}
+
+// pendingEvent is an event notification received before its SID was known
+type pendingEvent struct {
+	sid  string
+	seq  int64 // -1 if unknown
+	vars map[string]string
+}
+
+type propertySet struct {
+	XMLName    xml.Name `xml:"propertyset"`
+	Properties []struct {
+		Variables []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"property"`
+}
+
+// Subscribe subscribes to events from the service at eventSubURL,
+// and automatically renews the subscription before it expires.
+// The subscription lasts until ctx is cancelled or Close is called,
+// after which the service is unsubscribed from and the callback server stopped.
+func Subscribe(ctx context.Context, httpClient *http.Client, eventSubURL string, timeout time.Duration, handler Handler) (*Subscription, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	s := &Subscription{
+		httpClient:  httpClient,
+		eventSubURL: eventSubURL,
+		timeout:     timeout,
+		handler:     handler,
+		done:        make(chan struct{}),
+	}
+
+	listener, err := listenForService(eventSubURL)
+	if err != nil {
+		return nil, fmt.Errorf("GENA callback listen error: %w", err)
+	}
+	s.callbackURL = callbackURL(listener.Addr().(*net.TCPAddr))
+	s.server = &http.Server{Handler: http.HandlerFunc(s.handleNotify)}
+	go s.server.Serve(listener)
+
+	if err := s.subscribe(ctx); err != nil {
+		s.server.Close()
+		return nil, err
+	}
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	go s.renewLoop()
+	return s, nil
+}
+
+// SID returns the current subscription identifier
+func (s *Subscription) SID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sid
+}
+
+// Close unsubscribes from the service and stops the callback server
+func (s *Subscription) Close() error {
+	s.cancel()
+	<-s.done
+	return s.closeErr
+}
+
+// stop unsubscribes from the service and stops the callback server
+func (s *Subscription) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+	defer cancel()
+	s.closeErr = s.unsubscribe(ctx)
+	s.server.Close()
+}
+
+// listenForService listens on the local address from which the service host is reachable
+func listenForService(eventSubURL string) (net.Listener, error) {
+	u, err := url.Parse(eventSubURL)
+	if err != nil {
+		return nil, err
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+	}
+	// no packets are sent by "dialing" UDP; this only selects the local route
+	conn, err := net.Dial("udp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return nil, err
+	}
+	local := conn.LocalAddr().(*net.UDPAddr)
+	conn.Close()
+
+	// keep the zone of link-local IPv6 addresses, without which they cannot be listened on
+	host := local.IP.String()
+	if local.Zone != "" {
+		host += "%" + local.Zone
+	}
+	return net.Listen("tcp", net.JoinHostPort(host, "0"))
+}
+
+// callbackURL returns the URL of the callback server listening on addr.
+// IPv6 hosts are bracketed, and their zone escaped.
+func callbackURL(addr *net.TCPAddr) string {
+	host := addr.IP.String()
+	if addr.Zone != "" {
+		host += "%" + addr.Zone
+	}
+	u := url.URL{Scheme: "http", Host: net.JoinHostPort(host, strconv.Itoa(addr.Port)), Path: "/"}
+	return u.String()
+}
+
+func (s *Subscription) renewLoop() {
+	defer close(s.done)
+	defer s.stop()
+	for {
+		s.mu.Lock()
+		wait := s.expires / 2
+		s.mu.Unlock()
+		if wait <= 0 {
+			// infinite subscription, never needs renewal
+			<-s.ctx.Done()
+			return
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-s.ctx.Done():
+			return
+		}
+		for s.renew(s.ctx) != nil && s.subscribe(s.ctx) != nil {
+			select {
+			case <-time.After(retryInterval):
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (s *Subscription) subscribe(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "SUBSCRIBE", s.eventSubURL, nil)
+	if err != nil {
+		return fmt.Errorf("GENA SUBSCRIBE error: %w", err)
+	}
+	req.Header = http.Header{
+		"CALLBACK":   []string{"<" + s.callbackURL + ">"},
+		"NT":         []string{"upnp:event"},
+		"TIMEOUT":    []string{formatTimeout(s.timeout)},
+		"Connection": []string{"close"},
+	}
+
+	// devices send the initial event right after the SUBSCRIBE response,
+	// possibly before it is received, so events are kept until the SID is known
+	s.mu.Lock()
+	s.sid = ""
+	s.subscribing = true
+	s.pending = nil
+	s.mu.Unlock()
+
+	sid, expires, err := s.doSubscribe(req)
+
+	s.deliverMu.Lock()
+	defer s.deliverMu.Unlock()
+	s.mu.Lock()
+	pending := s.pending
+	s.subscribing = false
+	s.pending = nil
+	if err == nil {
+		s.sid, s.expires, s.nextSeq = sid, expires, 0
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	slices.SortStableFunc(pending, func(a, b pendingEvent) int { return cmp.Compare(a.seq, b.seq) })
+	for _, e := range pending {
+		if e.sid == sid && s.acceptSeq(e.seq) {
+			s.handler(e.vars)
+		}
+	}
+	return nil
+}
+
+func (s *Subscription) renew(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "SUBSCRIBE", s.eventSubURL, nil)
+	if err != nil {
+		return fmt.Errorf("GENA renew SUBSCRIBE error: %w", err)
+	}
+	req.Header = http.Header{
+		"SID":        []string{s.SID()},
+		"TIMEOUT":    []string{formatTimeout(s.timeout)},
+		"Connection": []string{"close"},
+	}
+
+	_, expires, err := s.doSubscribe(req)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.expires = expires
+	s.mu.Unlock()
+	return nil
+}
+
+// doSubscribe sends a SUBSCRIBE request,
+// and returns the SID and the subscription's duration, or 0 if it is infinite
+func (s *Subscription) doSubscribe(req *http.Request) (string, time.Duration, error) {
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("GENA SUBSCRIBE Do error: %w", err)
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("GENA SUBSCRIBE failed: %s", res.Status)
+	}
+	sid := res.Header.Get("SID")
+	if sid == "" {
+		return "", 0, errors.New("GENA SUBSCRIBE response missing SID")
+	}
+	return sid, parseTimeout(res.Header.Get("TIMEOUT"), s.timeout), nil
+}
+
+func (s *Subscription) unsubscribe(ctx context.Context) error {
+	sid := s.SID()
+	if sid == "" {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, "UNSUBSCRIBE", s.eventSubURL, nil)
+	if err != nil {
+		return fmt.Errorf("GENA UNSUBSCRIBE error: %w", err)
+	}
+	req.Header = http.Header{
+		"SID":        []string{sid},
+		"Connection": []string{"close"},
+	}
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GENA UNSUBSCRIBE Do error: %w", err)
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GENA UNSUBSCRIBE failed: %s", res.Status)
+	}
+	return nil
+}
+
+func (s *Subscription) handleNotify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "NOTIFY" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Header.Get("NT") != "upnp:event" || r.Header.Get("NTS") != "upnp:propchange" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	sid := r.Header.Get("SID")
+	if sid == "" {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	seq := parseSeq(r.Header.Get("SEQ"))
+
+	vars, err := ParsePropertySet(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.deliverMu.Lock()
+	defer s.deliverMu.Unlock()
+	s.mu.Lock()
+	if s.subscribing {
+		// the SID of the subscription is not known yet
+		if len(s.pending) < maxPendingEvents {
+			s.pending = append(s.pending, pendingEvent{sid: sid, seq: seq, vars: vars})
+		}
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	known := sid == s.sid
+	s.mu.Unlock()
+	if !known {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if s.acceptSeq(seq) {
+		s.handler(vars)
+	}
+}
+
+// parseSeq parses a SEQ header, returning -1 if it is missing or invalid
+func parseSeq(header string) int64 {
+	seq, err := strconv.ParseUint(strings.TrimSpace(header), 10, 32)
+	if err != nil {
+		return -1
+	}
+	return int64(seq)
+}
+
+// acceptSeq returns true if an event with the sequence number seq is not a repeat
+// of an event already delivered, and updates the next expected sequence number.
+// Later events are accepted even if some were missed, since they carry the latest state.
+// Events without a sequence number are always accepted.
+func (s *Subscription) acceptSeq(seq int64) bool {
+	if seq < 0 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// the sequence number wraps from the maximum to 1, never to 0
+	if int32(uint32(seq)-s.nextSeq) < 0 {
+		return false
+	}
+	s.nextSeq = uint32(seq) + 1
+	if s.nextSeq == 0 {
+		s.nextSeq = 1
+	}
+	return true
+}
+
+// ParsePropertySet parses the body of an event notification
+// into a map of state variable names to values.
+func ParsePropertySet(r io.Reader) (map[string]string, error) {
+	var ps propertySet
+	if err := xml.NewDecoder(r).Decode(&ps); err != nil {
+		return nil, fmt.Errorf("GENA propertyset decode error: %w", err)
+	}
+
+	vars := make(map[string]string)
+	for _, p := range ps.Properties {
+		for _, v := range p.Variables {
+			vars[v.XMLName.Local] = v.Value
+		}
+	}
+	return vars, nil
+}
+
+func formatTimeout(d time.Duration) string {
+	return "Second-" + strconv.Itoa(int(d.Seconds()))
+}
+
+// parseTimeout parses a TIMEOUT header, returning 0 for an infinite subscription
+func parseTimeout(header string, def time.Duration) time.Duration {
+	secs, ok := strings.CutPrefix(strings.ToLower(strings.TrimSpace(header)), "second-")
+	if !ok {
+		return def
+	}
+	if secs == "infinite" {
+		return 0
+	}
+	n, err := strconv.Atoi(secs)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return time.Duration(n) * time.Second
+}
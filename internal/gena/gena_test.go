@@ -0,0 +1,213 @@
+package gena
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testPropertySet = `<?xml version="1.0"?><e:propertyset xmlns:e="urn:schemas-upnp-org:event-1-0"><e:property><LastChange>%s</LastChange></e:property></e:propertyset>`
+
+// testService is a fake evented service
+type testService struct {
+	mu           sync.Mutex
+	callbackURL  string
+	renewals     int
+	unsubscribed chan string
+}
+
+// startTestService starts a service that sends the initial event before
+// responding to SUBSCRIBE, as devices may, and allows subscriptions of 1 second
+func startTestService(t *testing.T) (*testService, *httptest.Server) {
+	t.Helper()
+	svc := &testService{unsubscribed: make(chan string, 1)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "SUBSCRIBE":
+			if sid := r.Header.Get("SID"); sid != "" {
+				svc.mu.Lock()
+				svc.renewals++
+				svc.mu.Unlock()
+				w.Header().Set("SID", sid)
+				w.Header().Set("TIMEOUT", "Second-1")
+				return
+			}
+			callbackURL := strings.Trim(r.Header.Get("CALLBACK"), "<>")
+			svc.mu.Lock()
+			svc.callbackURL = callbackURL
+			svc.mu.Unlock()
+			if err := sendNotify(callbackURL, "uuid:sub-1", 0, "initial"); err != nil {
+				t.Errorf("initial NOTIFY error: %s", err.Error())
+			}
+			w.Header().Set("SID", "uuid:sub-1")
+			w.Header().Set("TIMEOUT", "Second-1")
+		case "UNSUBSCRIBE":
+			svc.unsubscribed <- r.Header.Get("SID")
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return svc, srv
+}
+
+func sendNotify(callbackURL, sid string, seq int, value string) error {
+	req, err := http.NewRequest("NOTIFY", callbackURL, strings.NewReader(strings.Replace(testPropertySet, "%s", value, 1)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("NT", "upnp:event")
+	req.Header.Set("NTS", "upnp:propchange")
+	req.Header.Set("SID", sid)
+	req.Header.Set("SEQ", strconv.Itoa(seq))
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func TestSubscribe(t *testing.T) {
+	tt := []struct {
+		name  string
+		close func(cancel context.CancelFunc, sub *Subscription)
+	}{
+		{`Subscribe Close`, func(cancel context.CancelFunc, sub *Subscription) {
+			if err := sub.Close(); err != nil {
+				t.Fatalf("Subscribe Close: Failed to close due to %s", err.Error())
+			}
+		}},
+		{`Subscribe context cancelled`, func(cancel context.CancelFunc, sub *Subscription) { cancel() }},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, srv := startTestService(t)
+
+			var mu sync.Mutex
+			var got []string
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			sub, err := Subscribe(ctx, http.DefaultClient, srv.URL, time.Second, func(vars map[string]string) {
+				mu.Lock()
+				got = append(got, vars["LastChange"])
+				mu.Unlock()
+			})
+			if err != nil {
+				t.Fatalf("%s: Failed to subscribe due to %s", tc.name, err.Error())
+			}
+			if sub.SID() != "uuid:sub-1" {
+				t.Fatalf("%s: got SID: %s, want: uuid:sub-1.", tc.name, sub.SID())
+			}
+
+			svc.mu.Lock()
+			callbackURL := svc.callbackURL
+			svc.mu.Unlock()
+			sendNotify(callbackURL, "uuid:sub-1", 0, "repeated")
+			sendNotify(callbackURL, "uuid:sub-1", 2, "second")
+			sendNotify(callbackURL, "uuid:other", 3, "other")
+
+			mu.Lock()
+			if want := "initial second"; strings.Join(got, " ") != want {
+				t.Fatalf("%s: got events: %v, want: %s.", tc.name, got, want)
+			}
+			mu.Unlock()
+
+			// renewed at half the subscription's duration
+			time.Sleep(700 * time.Millisecond)
+			svc.mu.Lock()
+			renewals := svc.renewals
+			svc.mu.Unlock()
+			if renewals == 0 {
+				t.Fatalf("%s: got renewals: 0, want: > 0.", tc.name)
+			}
+
+			tc.close(cancel, sub)
+			select {
+			case sid := <-svc.unsubscribed:
+				if sid != "uuid:sub-1" {
+					t.Fatalf("%s: got UNSUBSCRIBE SID: %s, want: uuid:sub-1.", tc.name, sid)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("%s: got no UNSUBSCRIBE.", tc.name)
+			}
+			<-sub.done
+			if err := sendNotify(callbackURL, "uuid:sub-1", 3, "closed"); err == nil {
+				t.Fatalf("%s: callback server still running after close.", tc.name)
+			}
+		})
+	}
+}
+
+func TestAcceptSeq(t *testing.T) {
+	tt := []struct {
+		name    string
+		nextSeq uint32
+		seq     int64
+		want    bool
+	}{
+		{`acceptSeq Test #1`, 0, 0, true},
+		{`acceptSeq Test #2`, 5, 4, false},
+		{`acceptSeq Test #3`, 5, 9, true},
+		{`acceptSeq Test #4`, 5, -1, true},
+		{`acceptSeq Test #5`, 4294967295, 1, true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &Subscription{nextSeq: tc.nextSeq}
+			if got := s.acceptSeq(tc.seq); got != tc.want {
+				t.Fatalf("%s: got: %v, want: %v.", tc.name, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCallbackURL(t *testing.T) {
+	tt := []struct {
+		name string
+		addr *net.TCPAddr
+		want string
+	}{
+		{`callbackURL Test #1`, &net.TCPAddr{IP: net.IPv4(192, 168, 1, 2), Port: 8080}, "http://192.168.1.2:8080/"},
+		{`callbackURL Test #2`, &net.TCPAddr{IP: net.ParseIP("fd00::2"), Port: 8080}, "http://[fd00::2]:8080/"},
+		{`callbackURL Test #3`, &net.TCPAddr{IP: net.ParseIP("fe80::1"), Port: 8080, Zone: "eth0"}, "http://[fe80::1%25eth0]:8080/"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := callbackURL(tc.addr); got != tc.want {
+				t.Fatalf("%s: got: %v, want: %v.", tc.name, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestListenForServiceLinkLocal(t *testing.T) {
+	ifaces, _ := net.Interfaces()
+	for _, iface := range ifaces {
+		addrs, _ := iface.Addrs()
+		for _, a := range addrs {
+			ipNet, ok := a.(*net.IPNet)
+			if !ok || ipNet.IP.To4() != nil || !ipNet.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			eventSubURL := "http://[" + ipNet.IP.String() + "%25" + iface.Name + "]:8080/event"
+			listener, err := listenForService(eventSubURL)
+			if err != nil {
+				t.Fatalf("listenForService %s: Failed to listen due to %s", eventSubURL, err.Error())
+			}
+			defer listener.Close()
+			if zone := listener.Addr().(*net.TCPAddr).Zone; zone != iface.Name {
+				t.Fatalf("listenForService %s: got zone: %s, want: %s.", eventSubURL, zone, iface.Name)
+			}
+			return
+		}
+	}
+	t.Skip("no interface with a link-local IPv6 address")
+}
@@ -0,0 +1,369 @@
+// Package msearch sends SSDP M-SEARCH requests and collects the responses.
+// Unlike go-ssdp's Search, it searches from a single local interface and address,
+// so that searches on several interfaces can run in parallel
+// and each response is known to have been received on a given interface.
+package msearch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	// MulticastAddrIPv4 is the SSDP multicast group and port
+	MulticastAddrIPv4 = "239.255.255.250:1900"
+
+	// MulticastAddrIPv6LinkLocal and MulticastAddrIPv6SiteLocal
+	// are the link-local and site-local IPv6 SSDP multicast groups and port
+	MulticastAddrIPv6LinkLocal = "[FF02::C]:1900"
+	MulticastAddrIPv6SiteLocal = "[FF05::C]:1900"
+
+	// Port is the SSDP port, to which unicast searches are sent
+	Port = "1900"
+
+	// All is the search target matching all devices and services
+	All = "ssdp:all"
+)
+
+// Response is a response to an M-SEARCH request
+type Response struct {
+	// Search target of the response, e.g. a service type
+	ST     string
+	USN    string
+	Server string
+
+	// URL of the device description. For link-local IPv6 hosts,
+	// the zone of the interface the response was received on is added.
+	Location string
+
+	// Seconds the response is valid for, or -1 if not specified
+	MaxAge int
+
+	// Local interface the search was sent from, or nil for unicast searches
+	Interface *net.Interface
+
+	// Local address the search was sent from
+	LocalAddr net.IP
+}
+
+// Request configures an M-SEARCH
+type Request struct {
+	// Search targets, e.g. a device or service type, each sent in its own M-SEARCH.
+	// Default is All.
+	STs []string
+
+	// Maximum seconds devices may wait before responding, sent in the MX header
+	// of multicast searches to spread the responses. Default and maximum is WaitSec.
+	MX int
+
+	// How many times each M-SEARCH is resent, to make up for lost UDP packets
+	Retransmissions int
+
+	// How many seconds to wait for responses
+	WaitSec int
+}
+
+// retransmitInterval is the delay between retransmissions of the M-SEARCH requests
+const retransmitInterval = 500 * time.Millisecond
+
+func (r Request) searchTargets() []string {
+	if len(r.STs) == 0 {
+		return []string{All}
+	}
+	return r.STs
+}
+
+func (r Request) mx() int {
+	if r.MX <= 0 || r.MX > r.WaitSec {
+		return max(r.WaitSec, 1)
+	}
+	return r.MX
+}
+
+// Multicast sends M-SEARCH requests to the SSDP multicast groups from the local address
+// on the given interface, and returns the responses received within req.WaitSec seconds.
+// For an IPv6 local address, the search is sent to both the link-local and site-local groups.
+// If ifi is nil, the system-assigned multicast interface is used.
+// Responses are deduplicated by USN.
+func Multicast(ctx context.Context, ifi *net.Interface, localAddr net.IP, req Request) ([]Response, error) {
+	var list []Response
+	err := MulticastFunc(ctx, ifi, localAddr, req, func(res Response) { list = append(list, res) })
+	return list, err
+}
+
+// MulticastFunc is like Multicast, but calls fn with each response as soon as it is received.
+// If the search was sent, fn may be called even if a non-nil error is returned.
+func MulticastFunc(ctx context.Context, ifi *net.Interface, localAddr net.IP, req Request, fn func(Response)) error {
+	isIPv6 := localAddr.To4() == nil
+	network, groups := "udp4", []string{MulticastAddrIPv4}
+	if isIPv6 {
+		network, groups = "udp6", []string{MulticastAddrIPv6LinkLocal, MulticastAddrIPv6SiteLocal}
+	}
+	zone := ""
+	if ifi != nil {
+		zone = ifi.Name
+	}
+
+	laddr := &net.UDPAddr{IP: localAddr}
+	if localAddr.IsLinkLocalUnicast() {
+		laddr.Zone = zone
+	}
+	conn, err := net.ListenUDP(network, laddr)
+	if err != nil {
+		return fmt.Errorf("listen error: %w", err)
+	}
+	defer conn.Close()
+
+	if err := setMulticastOptions(conn, ifi, isIPv6); err != nil {
+		return err
+	}
+
+	var msgs []message
+	for _, group := range groups {
+		addr, err := net.ResolveUDPAddr(network, group)
+		if err != nil {
+			return err
+		}
+		if addr.IP.IsLinkLocalMulticast() {
+			addr.Zone = zone
+		}
+		for _, st := range req.searchTargets() {
+			msgs = append(msgs, message{addr: addr, data: searchMessage(group, st, req.mx())})
+		}
+	}
+	// some groups may be unreachable from the interface, e.g. site-local IPv6
+	if err := send(conn, msgs); err != nil {
+		return err
+	}
+	stop := retransmit(ctx, conn, msgs, req.Retransmissions)
+	defer stop()
+
+	return readResponses(ctx, conn, req.WaitSec, func(res Response) {
+		res.Interface = ifi
+		res.LocalAddr = localAddr
+		res.Location = addZone(res.Location, zone)
+		fn(res)
+	})
+}
+
+// message is an M-SEARCH request and the address to send it to
+type message struct {
+	addr *net.UDPAddr
+	data []byte
+}
+
+// searchMessage returns an M-SEARCH request for st,
+// without an MX header if mx is 0, as for unicast searches
+func searchMessage(host, st string, mx int) []byte {
+	msg := "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + host + "\r\n" +
+		"MAN: \"ssdp:discover\"\r\n"
+	if mx > 0 {
+		msg += "MX: " + strconv.Itoa(mx) + "\r\n"
+	}
+	msg += "ST: " + st + "\r\n" +
+		"\r\n"
+	return []byte(msg)
+}
+
+// send sends the messages on conn, failing only if none could be sent
+func send(conn *net.UDPConn, msgs []message) error {
+	var errs []error
+	for _, msg := range msgs {
+		if _, err := conn.WriteToUDP(msg.data, msg.addr); err != nil {
+			errs = append(errs, fmt.Errorf("send to %s error: %w", msg.addr, err))
+		}
+	}
+	if len(errs) == len(msgs) {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// retransmit resends the messages n times in the background, until the returned stop func is called
+func retransmit(ctx context.Context, conn *net.UDPConn, msgs []message, n int) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(retransmitInterval)
+		defer ticker.Stop()
+		for i := 0; i < n; i++ {
+			select {
+			case <-ticker.C:
+				send(conn, msgs)
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// setMulticastOptions sets the interface, TTL and loopback of multicast packets sent on conn
+func setMulticastOptions(conn *net.UDPConn, ifi *net.Interface, isIPv6 bool) error {
+	// the UPnP device architecture recommends a TTL of 2
+	if isIPv6 {
+		pconn := ipv6.NewPacketConn(conn)
+		if ifi != nil {
+			if err := pconn.SetMulticastInterface(ifi); err != nil {
+				return fmt.Errorf("set multicast interface %s error: %w", ifi.Name, err)
+			}
+		}
+		pconn.SetMulticastHopLimit(2)
+		pconn.SetMulticastLoopback(true)
+		return nil
+	}
+
+	pconn := ipv4.NewPacketConn(conn)
+	if ifi != nil {
+		if err := pconn.SetMulticastInterface(ifi); err != nil {
+			return fmt.Errorf("set multicast interface %s error: %w", ifi.Name, err)
+		}
+	}
+	pconn.SetMulticastTTL(2)
+	pconn.SetMulticastLoopback(true)
+	return nil
+}
+
+// addZone adds the zone to the host of location if it is a link-local IPv6 address without one,
+// since the address is ambiguous without it
+func addZone(location, zone string) string {
+	u, err := url.Parse(location)
+	if err != nil || zone == "" {
+		return location
+	}
+	ip := net.ParseIP(u.Hostname())
+	if ip == nil || ip.To4() != nil || !ip.IsLinkLocalUnicast() {
+		return location
+	}
+	host := ip.String() + "%" + zone
+	if port := u.Port(); port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	} else {
+		u.Host = "[" + host + "]"
+	}
+	return u.String()
+}
+
+// Unicast sends M-SEARCH requests directly to host, rather than to the multicast group,
+// and returns the responses received within req.WaitSec seconds.
+// The host may include a port; the default is the SSDP port.
+// Responses are deduplicated by USN.
+func Unicast(ctx context.Context, host string, req Request) ([]Response, error) {
+	var list []Response
+	err := UnicastFunc(ctx, host, req, func(res Response) { list = append(list, res) })
+	return list, err
+}
+
+// UnicastFunc is like Unicast, but calls fn with each response as soon as it is received.
+// If the search was sent, fn may be called even if a non-nil error is returned.
+func UnicastFunc(ctx context.Context, host string, req Request, fn func(Response)) error {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, Port)
+	}
+	addr, err := net.ResolveUDPAddr("udp", host)
+	if err != nil {
+		return fmt.Errorf("resolve error: %w", err)
+	}
+
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return fmt.Errorf("listen error: %w", err)
+	}
+	defer conn.Close()
+
+	// unicast searches carry no MX header, the device responds immediately
+	var msgs []message
+	for _, st := range req.searchTargets() {
+		msgs = append(msgs, message{addr: addr, data: searchMessage(addr.String(), st, 0)})
+	}
+	if err := send(conn, msgs); err != nil {
+		return err
+	}
+	stop := retransmit(ctx, conn, msgs, req.Retransmissions)
+	defer stop()
+
+	return readResponses(ctx, conn, req.WaitSec, fn)
+}
+
+// readResponses reads responses from conn and calls fn with each, until waitSec seconds
+// have passed or the context is done, skipping repeated responses with the same USN
+func readResponses(ctx context.Context, conn *net.UDPConn, waitSec int, fn func(Response)) error {
+	deadline := time.Now().Add(time.Duration(waitSec) * time.Second)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetReadDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	seen := make(map[string]bool)
+	buf := make([]byte, 65535)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			return fmt.Errorf("receive error: %w", err)
+		}
+		res, err := parseResponse(buf[:n])
+		if err != nil {
+			continue
+		}
+		// devices answer every retransmission, and each group and search target it matches
+		key := res.USN
+		if key == "" {
+			key = res.ST + " " + res.Location
+		}
+		if !seen[key] {
+			seen[key] = true
+			fn(res)
+		}
+	}
+	return ctx.Err()
+}
+
+var maxAgeRegexp = regexp.MustCompile(`\bmax-age\s*=\s*(\d+)\b`)
+
+// parseResponse parses an M-SEARCH response
+func parseResponse(data []byte) (Response, error) {
+	if !bytes.HasPrefix(data, []byte("HTTP")) {
+		return Response{}, errors.New("response without HTTP prefix")
+	}
+	// some devices omit the blank line ending the header
+	if !bytes.HasSuffix(data, []byte("\r\n\r\n")) {
+		data = append(bytes.TrimRight(data, "\r\n"), "\r\n\r\n"...)
+	}
+	res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), nil)
+	if err != nil {
+		return Response{}, err
+	}
+	res.Body.Close()
+
+	maxAge := -1
+	if m := maxAgeRegexp.FindStringSubmatch(res.Header.Get("CACHE-CONTROL")); m != nil {
+		maxAge, _ = strconv.Atoi(m[1])
+	}
+	return Response{
+		ST:       res.Header.Get("ST"),
+		USN:      res.Header.Get("USN"),
+		Location: res.Header.Get("LOCATION"),
+		Server:   res.Header.Get("SERVER"),
+		MaxAge:   maxAge,
+	}, nil
+}
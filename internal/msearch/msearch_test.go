@@ -0,0 +1,133 @@
+package msearch
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseResponse(t *testing.T) {
+	tt := []struct {
+		name     string
+		response string
+		want     Response
+	}{
+		{
+			`parseResponse Test #1`,
+			"HTTP/1.1 200 OK\r\nCACHE-CONTROL: max-age = 1800\r\nEXT:\r\nLOCATION: http://192.168.1.20:49152/description.xml\r\nSERVER: Linux UPnP/1.0\r\nST: urn:schemas-upnp-org:service:AVTransport:1\r\nUSN: uuid:tv::urn:schemas-upnp-org:service:AVTransport:1\r\n\r\n",
+			Response{
+				ST:       "urn:schemas-upnp-org:service:AVTransport:1",
+				USN:      "uuid:tv::urn:schemas-upnp-org:service:AVTransport:1",
+				Location: "http://192.168.1.20:49152/description.xml",
+				Server:   "Linux UPnP/1.0",
+				MaxAge:   1800,
+			},
+		},
+		{
+			`parseResponse Test #2`,
+			"HTTP/1.1 200 OK\r\nLOCATION: http://192.168.1.21/dd.xml\r\nST: upnp:rootdevice\r\n",
+			Response{
+				ST:       "upnp:rootdevice",
+				Location: "http://192.168.1.21/dd.xml",
+				MaxAge:   -1,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := parseResponse([]byte(tc.response))
+			if err != nil {
+				t.Fatalf("%s: Failed to call parseResponse due to %s", tc.name, err.Error())
+			}
+			if out.ST != tc.want.ST || out.USN != tc.want.USN || out.Location != tc.want.Location ||
+				out.Server != tc.want.Server || out.MaxAge != tc.want.MaxAge {
+				t.Fatalf("%s: got: %+v, want: %+v.", tc.name, out, tc.want)
+			}
+		})
+	}
+
+	if _, err := parseResponse([]byte("NOTIFY * HTTP/1.1\r\n\r\n")); err == nil {
+		t.Fatalf("parseResponse NOTIFY: got no error.")
+	}
+}
+
+func TestAddZone(t *testing.T) {
+	tt := []struct {
+		name     string
+		location string
+		zone     string
+		want     string
+	}{
+		{`addZone Test #1`, "http://[fe80::1]:49152/dd.xml", "eth0", "http://[fe80::1%25eth0]:49152/dd.xml"},
+		{`addZone Test #2`, "http://[fe80::1]/dd.xml", "eth0", "http://[fe80::1%25eth0]/dd.xml"},
+		{`addZone Test #3`, "http://[fd00::20]:49152/dd.xml", "eth0", "http://[fd00::20]:49152/dd.xml"},
+		{`addZone Test #4`, "http://192.168.1.20:49152/dd.xml", "eth0", "http://192.168.1.20:49152/dd.xml"},
+		{`addZone Test #5`, "http://[fe80::1%25eth1]:49152/dd.xml", "eth0", "http://[fe80::1%25eth1]:49152/dd.xml"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := addZone(tc.location, tc.zone); got != tc.want {
+				t.Fatalf("%s: got: %v, want: %v.", tc.name, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUnicast(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Skipf("failed to start SSDP responder: %s", err.Error())
+	}
+	defer conn.Close()
+
+	var mu sync.Mutex
+	var requests []string
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			_, st, _ := strings.Cut(string(buf[:n]), "\r\nST: ")
+			st, _, _ = strings.Cut(st, "\r\n")
+			mu.Lock()
+			requests = append(requests, st)
+			mu.Unlock()
+			resp := "HTTP/1.1 200 OK\r\n" +
+				"LOCATION: http://127.0.0.1/dd.xml\r\n" +
+				"ST: " + st + "\r\n" +
+				"USN: uuid:tv::" + st + "\r\n" +
+				"\r\n"
+			conn.WriteToUDP([]byte(resp), addr)
+		}
+	}()
+
+	req := Request{
+		STs:             []string{"urn:schemas-upnp-org:device:MediaRenderer:1", "urn:schemas-upnp-org:service:AVTransport:1"},
+		Retransmissions: 1,
+		WaitSec:         1,
+	}
+	responses, err := Unicast(context.Background(), conn.LocalAddr().String(), req)
+	if err != nil {
+		t.Fatalf("Unicast: Failed to search due to %s", err.Error())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := len(requests), 4; got != want {
+		t.Fatalf("Unicast requests: got: %v, want: %v.", got, want)
+	}
+	var got []string
+	for _, res := range responses {
+		got = append(got, res.ST)
+	}
+	if !slices.Equal(got, req.STs) {
+		t.Fatalf("Unicast responses: got: %v, want: %v.", got, req.STs)
+	}
+}
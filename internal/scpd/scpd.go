@@ -0,0 +1,125 @@
+// Package scpd fetches and parses UPnP service control protocol descriptions (SCPD),
+// which describe the actions and state variables a service implements.
+package scpd
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Document is a parsed service description
+type Document struct {
+	XMLName        xml.Name        `xml:"scpd"`
+	Actions        []Action        `xml:"actionList>action"`
+	StateVariables []StateVariable `xml:"serviceStateTable>stateVariable"`
+}
+
+// Action is an action implemented by the service
+type Action struct {
+	Name      string     `xml:"name"`
+	Arguments []Argument `xml:"argumentList>argument"`
+}
+
+// Argument is an input or output argument of an action
+type Argument struct {
+	Name string `xml:"name"`
+
+	// "in" or "out"
+	Direction string `xml:"direction"`
+
+	// Name of the state variable that defines the argument's type and allowed values
+	RelatedStateVariable string `xml:"relatedStateVariable"`
+}
+
+// StateVariable is a state variable of the service,
+// which may also describe the allowed values of an action argument
+type StateVariable struct {
+	// "yes" if changes to the variable are evented
+	SendEvents string `xml:"sendEvents,attr"`
+
+	Name              string             `xml:"name"`
+	DataType          string             `xml:"dataType"`
+	AllowedValues     []string           `xml:"allowedValueList>allowedValue"`
+	AllowedValueRange *AllowedValueRange `xml:"allowedValueRange"`
+}
+
+// AllowedValueRange is the range of values allowed for a numeric state variable.
+// Step is 1 if not specified by the service. Minimum and Maximum are 0
+// if the service specifies values that are not integers.
+type AllowedValueRange struct {
+	Minimum int
+	Maximum int
+	Step    int
+}
+
+type allowedValueRangeXML struct {
+	Minimum string `xml:"minimum"`
+	Maximum string `xml:"maximum"`
+	Step    string `xml:"step"`
+}
+
+func (r *AllowedValueRange) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var x allowedValueRangeXML
+	if err := d.DecodeElement(&x, &start); err != nil {
+		return err
+	}
+	min, errMin := strconv.Atoi(strings.TrimSpace(x.Minimum))
+	max, errMax := strconv.Atoi(strings.TrimSpace(x.Maximum))
+	if errMin == nil && errMax == nil {
+		r.Minimum, r.Maximum = min, max
+	}
+	r.Step = 1
+	if step, err := strconv.Atoi(strings.TrimSpace(x.Step)); err == nil && step > 0 {
+		r.Step = step
+	}
+	return nil
+}
+
+// Fetch fetches and parses the service description at scpdURL
+func Fetch(ctx context.Context, httpClient *http.Client, scpdURL string) (*Document, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scpdURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("setup GET SCPD error: %w", err)
+	}
+	req.Header.Set("Connection", "close")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do GET SCPD error: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET SCPD HTTP error: %s", res.Status)
+	}
+
+	var doc Document
+	if err := xml.NewDecoder(res.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("unmarshal SCPD error: %w", err)
+	}
+	return &doc, nil
+}
+
+// Action returns the named action, or nil if the service does not implement it
+func (d *Document) Action(name string) *Action {
+	for i := range d.Actions {
+		if d.Actions[i].Name == name {
+			return &d.Actions[i]
+		}
+	}
+	return nil
+}
+
+// StateVariable returns the named state variable, or nil if the service does not have it
+func (d *Document) StateVariable(name string) *StateVariable {
+	for i := range d.StateVariables {
+		if d.StateVariables[i].Name == name {
+			return &d.StateVariables[i]
+		}
+	}
+	return nil
+}
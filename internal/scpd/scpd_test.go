@@ -0,0 +1,104 @@
+package scpd
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+const testSCPD = `<?xml version="1.0"?>
+<scpd xmlns="urn:schemas-upnp-org:service-1-0">
+  <actionList>
+    <action>
+      <name>SetVolume</name>
+      <argumentList>
+        <argument><name>InstanceID</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_InstanceID</relatedStateVariable></argument>
+        <argument><name>Channel</name><direction>in</direction><relatedStateVariable>A_ARG_TYPE_Channel</relatedStateVariable></argument>
+        <argument><name>DesiredVolume</name><direction>in</direction><relatedStateVariable>Volume</relatedStateVariable></argument>
+      </argumentList>
+    </action>
+  </actionList>
+  <serviceStateTable>
+    <stateVariable sendEvents="no">
+      <name>A_ARG_TYPE_Channel</name>
+      <dataType>string</dataType>
+      <allowedValueList><allowedValue>Master</allowedValue><allowedValue>LF</allowedValue><allowedValue>RF</allowedValue></allowedValueList>
+    </stateVariable>
+    <stateVariable sendEvents="no">
+      <name>Volume</name>
+      <dataType>ui2</dataType>
+      <allowedValueRange><minimum>0</minimum><maximum>100</maximum><step>5</step></allowedValueRange>
+    </stateVariable>
+  </serviceStateTable>
+</scpd>`
+
+func TestAllowedValueRange(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		want  AllowedValueRange
+	}{
+		{`AllowedValueRange Test #1`, `<allowedValueRange><minimum>0</minimum><maximum>100</maximum><step>5</step></allowedValueRange>`, AllowedValueRange{0, 100, 5}},
+		{`AllowedValueRange Test #2`, `<allowedValueRange><minimum> -10 </minimum><maximum>10</maximum></allowedValueRange>`, AllowedValueRange{-10, 10, 1}},
+		{`AllowedValueRange Test #3`, `<allowedValueRange><minimum>0</minimum><maximum>100</maximum><step>0</step></allowedValueRange>`, AllowedValueRange{0, 100, 1}},
+		{`AllowedValueRange Test #4`, `<allowedValueRange><minimum>0.0</minimum><maximum>1.0</maximum><step>0.1</step></allowedValueRange>`, AllowedValueRange{0, 0, 1}},
+		{`AllowedValueRange Test #5`, `<allowedValueRange><minimum>0</minimum><maximum>max</maximum></allowedValueRange>`, AllowedValueRange{0, 0, 1}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var out AllowedValueRange
+			if err := xml.Unmarshal([]byte(tc.input), &out); err != nil {
+				t.Fatalf("%s: Failed to unmarshal due to %s", tc.name, err.Error())
+			}
+			if out != tc.want {
+				t.Fatalf("%s: got: %+v, want: %+v.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/scpd.xml":
+			w.Write([]byte(testSCPD))
+		case "/invalid.xml":
+			w.Write([]byte("<scpd><actionList>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	doc, err := Fetch(context.Background(), http.DefaultClient, srv.URL+"/scpd.xml")
+	if err != nil {
+		t.Fatalf("Failed to fetch due to %s", err.Error())
+	}
+
+	action := doc.Action("SetVolume")
+	if action == nil || len(action.Arguments) != 3 || action.Arguments[2].RelatedStateVariable != "Volume" {
+		t.Fatalf("Fetch SetVolume: got: %+v, want: 3 arguments.", action)
+	}
+	if doc.Action("GetVolume") != nil {
+		t.Fatalf("Fetch GetVolume: got action, want: nil.")
+	}
+
+	channel := doc.StateVariable("A_ARG_TYPE_Channel")
+	if want := []string{"Master", "LF", "RF"}; channel == nil || !slices.Equal(channel.AllowedValues, want) || channel.AllowedValueRange != nil {
+		t.Fatalf("Fetch A_ARG_TYPE_Channel: got: %+v, want: %v.", channel, want)
+	}
+	volume := doc.StateVariable("Volume")
+	if want := (AllowedValueRange{0, 100, 5}); volume == nil || volume.AllowedValueRange == nil || *volume.AllowedValueRange != want {
+		t.Fatalf("Fetch Volume: got: %+v, want: %+v.", volume, want)
+	}
+
+	for _, path := range []string{"/missing.xml", "/invalid.xml"} {
+		if _, err := Fetch(context.Background(), http.DefaultClient, srv.URL+path); err == nil {
+			t.Fatalf("Fetch %s: got no error, want error.", path)
+		}
+	}
+}
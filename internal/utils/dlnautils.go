@@ -108,6 +108,12 @@ func BuildContentFeatures(mediaType string, seek string, transcode bool) (string
 	return cf.String(), nil
 }
 
+// DLNAProfile returns the DLNA.ORG_PN media format profile
+// used for the given MIME type, or "" if there is none.
+func DLNAProfile(mediaType string) string {
+	return strings.TrimPrefix(dlnaprofiles[mediaType], "DLNA.ORG_PN=")
+}
+
 // GetMimeDetails returns the media mime details.
 func GetMimeDetails(f io.ReadCloser) (string, error) {
 	defer f.Close()
@@ -194,11 +200,42 @@ func FormatClockTime(strtime string) (string, error) {
 	return out, nil
 }
 
-// ParseDuration parses a HH:MM:SS or MM:SS formatted string
-// into a [time.Duration] value.
+// FormatDuration formats a [time.Duration] value as a HH:MM:SS clock time,
+// with millisecond precision if the duration has a fractional second.
+func FormatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	d = d.Round(time.Millisecond)
+	hours := d / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+	seconds := (d % time.Minute) / time.Second
+	millis := (d % time.Second) / time.Millisecond
+
+	str := fmt.Sprintf("%s%02d:%02d:%02d", sign, hours, minutes, seconds)
+	if millis != 0 {
+		str += fmt.Sprintf(".%03d", millis)
+	}
+	return str
+}
+
+// ParseDuration parses a H+:MM:SS or MM:SS formatted string
+// into a [time.Duration] value. The seconds may have a fraction
+// in either the decimal (SS.F+) or the rational (SS.F0/F1) form,
+// and the value may have a leading sign.
 func ParseDuration(durStr string) (time.Duration, error) {
+	durStr = strings.TrimSpace(durStr)
+	sign := time.Duration(1)
+	if rest, ok := strings.CutPrefix(durStr, "-"); ok {
+		sign, durStr = -1, rest
+	} else {
+		durStr = strings.TrimPrefix(durStr, "+")
+	}
+
 	parts := strings.Split(durStr, ":")
 	var hours, minutes, seconds int
+	var fraction time.Duration
 	var err error
 
 	if len(parts) == 2 {
@@ -207,12 +244,12 @@ func ParseDuration(durStr string) (time.Duration, error) {
 		if err != nil {
 			return 0, fmt.Errorf("invalid minutes: %w", err)
 		}
-		seconds, err = strconv.Atoi(parts[1])
+		seconds, fraction, err = parseClockSeconds(parts[1])
 		if err != nil {
-			return 0, fmt.Errorf("invalid seconds: %w", err)
+			return 0, err
 		}
 	} else if len(parts) == 3 {
-		// Format HH:MM:SS
+		// Format H+:MM:SS
 		hours, err = strconv.Atoi(parts[0])
 		if err != nil {
 			return 0, fmt.Errorf("invalid hours: %w", err)
@@ -221,14 +258,50 @@ func ParseDuration(durStr string) (time.Duration, error) {
 		if err != nil {
 			return 0, fmt.Errorf("invalid minutes: %w", err)
 		}
-		seconds, err = strconv.Atoi(parts[2])
+		seconds, fraction, err = parseClockSeconds(parts[2])
 		if err != nil {
-			return 0, fmt.Errorf("invalid seconds: %w", err)
+			return 0, err
 		}
 	} else {
 		return 0, fmt.Errorf("invalid format: expected MM:SS or HH:MM:SS")
 	}
 
-	duration := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
-	return duration, nil
+	duration := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second + fraction
+	return sign * duration, nil
+}
+
+// parseClockSeconds parses the SS, SS.F+ or SS.F0/F1 seconds part of a clock value
+func parseClockSeconds(s string) (int, time.Duration, error) {
+	secStr, fracStr, hasFrac := strings.Cut(s, ".")
+	seconds, err := strconv.Atoi(secStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid seconds: %w", err)
+	}
+	if !hasFrac {
+		return seconds, 0, nil
+	}
+
+	if f0, f1, ok := strings.Cut(fracStr, "/"); ok {
+		num, err := strconv.Atoi(f0)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid fraction: %w", err)
+		}
+		den, err := strconv.Atoi(f1)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid fraction: %w", err)
+		}
+		if num < 0 || den <= 0 || num >= den {
+			return 0, 0, fmt.Errorf("invalid fraction: %s", fracStr)
+		}
+		return seconds, time.Duration(num) * time.Second / time.Duration(den), nil
+	}
+
+	if fracStr == "" || strings.TrimLeft(fracStr, "0123456789") != "" {
+		return 0, 0, fmt.Errorf("invalid fraction: %s", fracStr)
+	}
+	frac, err := strconv.ParseFloat("0."+fracStr, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid fraction: %w", err)
+	}
+	return seconds, time.Duration(math.Round(frac * float64(time.Second))), nil
 }
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tt := []struct {
+		name    string
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{`ParseDuration Test #1`, "01:02:03", time.Hour + 2*time.Minute + 3*time.Second, false},
+		{`ParseDuration Test #2`, "3:25", 3*time.Minute + 25*time.Second, false},
+		{`ParseDuration Test #3`, "100:00:00", 100 * time.Hour, false},
+		{`ParseDuration Test #4`, "0:00:01.250", 1250 * time.Millisecond, false},
+		{`ParseDuration Test #5`, "0:00:01.1/4", 1250 * time.Millisecond, false},
+		{`ParseDuration Test #6`, "-0:00:10", -10 * time.Second, false},
+		{`ParseDuration Test #7`, "NOT_IMPLEMENTED", 0, true},
+		{`ParseDuration Test #8`, "0:00:01.", 0, true},
+		{`ParseDuration Test #9`, "0:00:01.4/2", 0, true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := ParseDuration(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("%s: got error: %v, want error: %t.", tc.name, err, tc.wantErr)
+			}
+			if out != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
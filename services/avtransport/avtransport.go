@@ -1,21 +1,37 @@
 package avtransport
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"encoding/xml"
+	"errors"
 	"fmt"
-	"io"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/supersonic-app/go-upnpcast/internal/utils"
+	"github.com/supersonic-app/go-upnpcast/services"
+	"github.com/supersonic-app/go-upnpcast/soap"
 )
 
+// ErrUnsupportedAction is returned by the methods for actions that require
+// a later version of the AVTransport service than the device's
+var ErrUnsupportedAction = errors.New("the action requires a later AVTransport version")
+
 type Client struct {
 	HTTPClient *http.Client
-	controlURL string
+
+	// Service type advertised by the device, used in SOAP requests.
+	// Defaults to AVTransport:1.
+	ServiceType services.Type
+
+	controlURL  string
+	eventSubURL string
+
+	mu     sync.Mutex
+	events *eventSubscription
 }
 
 // MediaItem represents a media item to be rendered by the device.
@@ -28,27 +44,194 @@ type MediaItem struct {
 	ContentType  string
 	Seekable     bool
 	Duration     time.Duration
+
+	// protocolInfo for the media's DIDL-Lite res node. Optional.
+	// If empty, it is generated from ContentType and Seekable.
+	// See device.MediaRenderer.CanPlay for finding the best match for a renderer.
+	ProtocolInfo string
 }
 
 // TransportInfo is the information returned by GetTransportInfo
 type TransportInfo struct {
-	Status string
-	State  string
-	Speed  string
+	Status TransportStatus
+	State  TransportState
+
+	// Zero value if the device reported an unparsable speed
+	Speed PlaySpeed
 }
 
+// UnknownDuration is reported for a position or duration
+// that the device does not know or does not implement
+const UnknownDuration time.Duration = -1
+
+// UnknownCount is reported for a position counter
+// that the device does not know or does not implement
+const UnknownCount = -1
+
 // PositionInfo is the duration and current playback position of the current media item,
 // returned by GetPositionInfo
 type PositionInfo struct {
+	// Sequence number of the current track, or 0 if no track is loaded
+	Track int
+
+	// Duration of the current track, or UnknownDuration
 	Duration time.Duration
-	RelTime  time.Duration
+
+	// Metadata of the current track, or nil if the device did not report any
+	TrackMetaData *MediaItem
+
+	TrackURI string
+
+	// Position relative to the start of the current track, or UnknownDuration
+	RelTime time.Duration
+
+	// Position relative to the start of the whole media, or UnknownDuration
+	AbsTime time.Duration
+
+	// Counter positions relative to the start of the current track
+	// and the whole media, or UnknownCount
+	RelCount int
+	AbsCount int
+}
+
+// MediaInfo is the information about the currently loaded media, returned by GetMediaInfo
+type MediaInfo struct {
+	// Number of tracks in the current media
+	NrTracks int
+
+	// Duration of the current media, or UnknownDuration if unknown
+	MediaDuration time.Duration
+
+	CurrentURI string
+
+	// Metadata of the current media, or nil if the device did not report any
+	CurrentURIMetaData *MediaItem
+
+	NextURI string
+
+	// Metadata of the next media, or nil if the device did not report any
+	NextURIMetaData *MediaItem
+
+	// Storage medium of the media being played, e.g. "NETWORK" or "NONE"
+	PlayMedium string
+
+	// Storage medium used for recording, e.g. "NOT_IMPLEMENTED"
+	RecordMedium string
+
+	// Write protection status of the record medium
+	WriteStatus string
+}
+
+// MediaType describes the currently loaded media, reported by GetMediaInfoExt
+type MediaType string
+
+const (
+	MediaTypeNoMedia      MediaType = "NO_MEDIA"
+	MediaTypeTrackAware   MediaType = "TRACK_AWARE"
+	MediaTypeTrackUnaware MediaType = "TRACK_UNAWARE"
+)
+
+// MediaInfoExt is the information returned by GetMediaInfoExt
+type MediaInfoExt struct {
+	MediaInfo
+
+	// Whether the current media consists of tracks, e.g. a playlist,
+	// or is a single stream such as a radio station
+	CurrentType MediaType
+}
+
+// PlayMode is the play mode of the transport, set by SetPlayMode
+type PlayMode string
+
+// Play modes defined by the AVTransport spec.
+// Devices may support only some of these; see the SCPD's allowed values for CurrentPlayMode.
+const (
+	PlayModeNormal    PlayMode = "NORMAL"
+	PlayModeShuffle   PlayMode = "SHUFFLE"
+	PlayModeRepeatOne PlayMode = "REPEAT_ONE"
+	PlayModeRepeatAll PlayMode = "REPEAT_ALL"
+	PlayModeRandom    PlayMode = "RANDOM"
+	PlayModeDirect1   PlayMode = "DIRECT_1"
+	PlayModeIntro     PlayMode = "INTRO"
+)
+
+// DeviceCapabilities is the information returned by GetDeviceCapabilities
+type DeviceCapabilities struct {
+	// Storage media the device can play from, e.g. "NETWORK"
+	PlayMedia []string
+
+	// Storage media the device can record to
+	RecMedia []string
+
+	// Record quality modes the device supports
+	RecQualityModes []string
+}
+
+// TransportSettings is the information returned by GetTransportSettings
+type TransportSettings struct {
+	PlayMode       PlayMode
+	RecQualityMode string
+}
+
+// SeekUnit is the unit of a seek target
+type SeekUnit string
+
+// Seek units defined by the AVTransport spec, and the DLNA byte seek extension.
+// Devices may support only some of these; see the SCPD's allowed values for A_ARG_TYPE_SeekMode.
+const (
+	SeekUnitTrackNr  SeekUnit = "TRACK_NR"
+	SeekUnitAbsTime  SeekUnit = "ABS_TIME"
+	SeekUnitRelTime  SeekUnit = "REL_TIME"
+	SeekUnitAbsCount SeekUnit = "ABS_COUNT"
+	SeekUnitRelCount SeekUnit = "REL_COUNT"
+	SeekUnitRelByte  SeekUnit = "X_DLNA_REL_BYTE"
+)
+
+// SeekTarget is a position to seek to, passed to SeekTo
+type SeekTarget struct {
+	Unit SeekUnit
+
+	// Target position formatted for the unit
+	Target string
+}
+
+// SeekRelTime returns a target relative to the start of the current track
+func SeekRelTime(d time.Duration) SeekTarget {
+	return SeekTarget{Unit: SeekUnitRelTime, Target: utils.FormatDuration(d)}
+}
+
+// SeekAbsTime returns a target relative to the start of the whole media
+func SeekAbsTime(d time.Duration) SeekTarget {
+	return SeekTarget{Unit: SeekUnitAbsTime, Target: utils.FormatDuration(d)}
+}
+
+// SeekTrack returns a target at the start of the given track, numbered from 1
+func SeekTrack(track int) SeekTarget {
+	return SeekTarget{Unit: SeekUnitTrackNr, Target: strconv.Itoa(track)}
+}
+
+// SeekAbsCount returns a target counter position relative to the start of the whole media
+func SeekAbsCount(count int) SeekTarget {
+	return SeekTarget{Unit: SeekUnitAbsCount, Target: strconv.Itoa(count)}
+}
+
+// SeekRelCount returns a target counter position relative to the start of the current track
+func SeekRelCount(count int) SeekTarget {
+	return SeekTarget{Unit: SeekUnitRelCount, Target: strconv.Itoa(count)}
+}
+
+// SeekRelByte returns a target byte offset relative to the start of the current track
+func SeekRelByte(offset int64) SeekTarget {
+	return SeekTarget{Unit: SeekUnitRelByte, Target: strconv.FormatInt(offset, 10)}
 }
 
 // Should not be used directly. Use device.AVTransportClient() instead.
 func NewClient(controlURL, eventSubURL string) *Client {
 	return &Client{
-		HTTPClient: &http.Client{Timeout: 10 * time.Second},
-		controlURL: controlURL,
+		HTTPClient:  &http.Client{Timeout: 10 * time.Second},
+		ServiceType: services.AVTransport,
+		controlURL:  controlURL,
+		eventSubURL: eventSubURL,
 	}
 }
 
@@ -64,176 +247,308 @@ func (a *Client) Stop(ctx context.Context) error {
 	return a.playPauseStopSoapCall(ctx, "Stop")
 }
 
-func (a *Client) Seek(ctx context.Context, relSecs int) error {
-	time, err := utils.SecondsToClockTime(relSecs)
-	if err != nil {
-		return err
-	}
-	xml, err := seekSoapBuild(time)
+// Seek seeks to a position relative to the start of the current track
+func (a *Client) Seek(ctx context.Context, relTime time.Duration) error {
+	return a.SeekTo(ctx, SeekRelTime(relTime))
+}
+
+// SeekTo seeks to the given target, e.g. SeekTrack(2) to jump to the second track.
+// Returns ErrIllegalSeekTarget (wrapped) if the target is out of range.
+func (a *Client) SeekTo(ctx context.Context, target SeekTarget) error {
+	xml, err := seekSoapBuild(a.ServiceType, string(target.Unit), target.Target)
 	if err != nil {
 		return fmt.Errorf("SeekSoapCall action error: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", a.controlURL, bytes.NewReader(xml))
+	if _, err := a.soapCall(ctx, "Seek", xml); err != nil {
+		return fmt.Errorf("SeekSoapCall error: %w", err)
+	}
+	return nil
+}
+
+func (a *Client) SetAVTransportMedia(ctx context.Context, media *MediaItem) error {
+	soapCall, err := setAVTransportSoapBuild(a.ServiceType, media)
 	if err != nil {
-		return fmt.Errorf("SeekSoapCall POST error: %w", err)
+		return fmt.Errorf("SetAVTransportMedia build error: %w", err)
 	}
-	req.Header = utils.BuildRequestHeader(`"urn:schemas-upnp-org:service:AVTransport:1#Seek"`)
 
-	res, err := a.HTTPClient.Do(req)
+	if _, err := a.soapCall(ctx, "SetAVTransportURI", soapCall); err != nil {
+		return fmt.Errorf("SetAVTransportMedia error: %w", err)
+	}
+
+	return nil
+}
+
+func (a *Client) SetNextAVTransportMedia(ctx context.Context, media *MediaItem) error {
+	soapCall, err := setNextAVTransportSoapBuild(a.ServiceType, media)
 	if err != nil {
-		return fmt.Errorf("SeekSoapCall Do POST error: %w", err)
+		return fmt.Errorf("SetNextAVTransportMedia build error: %w", err)
+	}
+
+	if _, err := a.soapCall(ctx, "SetNextAVTransportURI", soapCall); err != nil {
+		return fmt.Errorf("SetNextAVTransportMedia error: %w", err)
 	}
-	defer res.Body.Close()
 
-	_, err = io.ReadAll(res.Body)
+	return nil
+}
+
+// GetTransportInfo
+func (a *Client) GetTransportInfo(ctx context.Context) (TransportInfo, error) {
+	xmlbuilder, err := getTransportInfoSoapBuild(a.ServiceType)
 	if err != nil {
-		return fmt.Errorf("SeekSoapCall Failed to read response: %w", err)
+		return TransportInfo{}, fmt.Errorf("GetTransportInfo build error: %w", err)
 	}
-	_, err = json.Marshal(res.Header)
+
+	r, err := a.soapCall(ctx, "GetTransportInfo", xmlbuilder)
 	if err != nil {
-		return fmt.Errorf("SeekSoapCall Response Marshaling error: %w", err)
+		return TransportInfo{}, fmt.Errorf("GetTransportInfo error: %w", err)
 	}
 
-	return nil
+	speed, _ := ParsePlaySpeed(r["CurrentSpeed"])
+	info := TransportInfo{
+		Status: ParseTransportStatus(r["CurrentTransportStatus"]),
+		State:  ParseTransportState(r["CurrentTransportState"]),
+		Speed:  speed,
+	}
 
+	return info, nil
 }
 
-func (a *Client) SetAVTransportMedia(ctx context.Context, media *MediaItem) error {
-	soapCall, err := setAVTransportSoapBuild(media)
+func (a *Client) GetPositionInfo(ctx context.Context) (PositionInfo, error) {
+	xmlRequest, err := getPositionInfoSoapBuild(a.ServiceType)
 	if err != nil {
-		return fmt.Errorf("SetAVTransportMedia build error: %w", err)
+		return PositionInfo{}, fmt.Errorf("GetPositionInfo build error: %w", err)
 	}
-	req, err := http.NewRequestWithContext(ctx, "POST", a.controlURL, bytes.NewReader(soapCall))
+
+	r, err := a.soapCall(ctx, "GetPositionInfo", xmlRequest)
 	if err != nil {
-		return fmt.Errorf("SetAVTransportMedia POST error: %w", err)
+		return PositionInfo{}, fmt.Errorf("GetPositionInfo error: %w", err)
+	}
+
+	info := PositionInfo{
+		TrackURI: r["TrackURI"],
+		AbsTime:  parsePositionTime(r["AbsTime"]),
+		RelCount: parsePositionCount(r["RelCount"]),
+		AbsCount: parsePositionCount(r["AbsCount"]),
 	}
-	req.Header = utils.BuildRequestHeader(`"urn:schemas-upnp-org:service:AVTransport:1#SetAVTransportURI"`)
-	res, err := a.HTTPClient.Do(req)
+	info.Track, _ = strconv.Atoi(strings.TrimSpace(r["Track"]))
+	// metadata is informational; devices often report none or invalid DIDL-Lite
+	info.TrackMetaData, _ = parseURIMetadata(r["TrackMetaData"], r["TrackURI"])
+
+	var err2 error
+	info.Duration, err = parsePositionDuration(r["TrackDuration"])
+	info.RelTime, err2 = parsePositionDuration(r["RelTime"])
+	if err2 != nil && err == nil {
+		err = err2
+	}
+
+	return info, err
+}
+
+// GetMediaInfo returns information about the media currently loaded in the device
+func (a *Client) GetMediaInfo(ctx context.Context) (MediaInfo, error) {
+	xmlRequest, err := getMediaInfoSoapBuild(a.ServiceType)
 	if err != nil {
-		return fmt.Errorf("SetAVTransportMedia Do POST error: %w", err)
+		return MediaInfo{}, fmt.Errorf("GetMediaInfo build error: %w", err)
 	}
-	defer res.Body.Close()
 
-	respBody, err := io.ReadAll(res.Body)
+	r, err := a.soapCall(ctx, "GetMediaInfo", xmlRequest)
 	if err != nil {
-		return fmt.Errorf("SetAVTransportMedia Failed to read response: %w", err)
+		return MediaInfo{}, fmt.Errorf("GetMediaInfo error: %w", err)
 	}
 
-	var resp setAVTransportURIResponse
-	if err := xml.Unmarshal(respBody, &resp); err != nil {
-		return fmt.Errorf("SetAVTransportMedia Failed to unmarshal response: %w", err)
+	return parseMediaInfo(r), nil
+}
+
+// GetMediaInfoExt returns information about the media currently loaded in the device,
+// including whether it is track-aware. Requires AVTransport:2.
+func (a *Client) GetMediaInfoExt(ctx context.Context) (MediaInfoExt, error) {
+	if err := a.requireVersion(2); err != nil {
+		return MediaInfoExt{}, fmt.Errorf("GetMediaInfo_Ext error: %w", err)
+	}
+	xmlRequest, err := getMediaInfoExtSoapBuild(a.ServiceType)
+	if err != nil {
+		return MediaInfoExt{}, fmt.Errorf("GetMediaInfo_Ext build error: %w", err)
 	}
 
-	return nil
+	r, err := a.soapCall(ctx, "GetMediaInfo_Ext", xmlRequest)
+	if err != nil {
+		return MediaInfoExt{}, fmt.Errorf("GetMediaInfo_Ext error: %w", err)
+	}
+
+	return MediaInfoExt{
+		MediaInfo:   parseMediaInfo(r),
+		CurrentType: MediaType(strings.TrimSpace(r["CurrentType"])),
+	}, nil
 }
 
-func (a *Client) SetNextAVTransportMedia(ctx context.Context, media *MediaItem) error {
-	soapCall, err := setNextAVTransportSoapBuild(media)
+// GetStateVariables returns the current values of the given state variables by name,
+// e.g. "TransportState" or "CurrentPlayMode". Pass "*" to get all state variables.
+// Requires AVTransport:2.
+func (a *Client) GetStateVariables(ctx context.Context, names ...string) (map[string]string, error) {
+	if err := a.requireVersion(2); err != nil {
+		return nil, fmt.Errorf("GetStateVariables error: %w", err)
+	}
+	xmlRequest, err := getStateVariablesSoapBuild(a.ServiceType, strings.Join(names, ","))
 	if err != nil {
-		return fmt.Errorf("SetNextAVTransportMedia build error: %w", err)
+		return nil, fmt.Errorf("GetStateVariables build error: %w", err)
 	}
-	req, err := http.NewRequestWithContext(ctx, "POST", a.controlURL, bytes.NewReader(soapCall))
+
+	r, err := a.soapCall(ctx, "GetStateVariables", xmlRequest)
 	if err != nil {
-		return fmt.Errorf("SetNextAVTransportMedia POST error: %w", err)
+		return nil, fmt.Errorf("GetStateVariables error: %w", err)
 	}
 
-	req.Header = utils.BuildRequestHeader(`"urn:schemas-upnp-org:service:AVTransport:1#SetNextAVTransportURI"`)
-	res, err := a.HTTPClient.Do(req)
+	values, err := parseStateVariableValuePairs(r["StateVariableValuePairs"])
 	if err != nil {
-		return fmt.Errorf("SetNextAVTransportMedia Do POST error: %w", err)
+		return nil, fmt.Errorf("GetStateVariables error: %w", err)
 	}
-	defer res.Body.Close()
+	return values, nil
+}
 
-	respBody, err := io.ReadAll(res.Body)
+// SetStateVariables sets the given state variables by name,
+// and returns the names of the variables that were set.
+// The avTransportUDN, serviceType and serviceID identify the service instance
+// the values were read from, e.g. when restoring a state saved by GetStateVariables,
+// and may be empty. Requires AVTransport:2.
+func (a *Client) SetStateVariables(ctx context.Context, avTransportUDN, serviceType, serviceID string, values map[string]string) ([]string, error) {
+	if err := a.requireVersion(2); err != nil {
+		return nil, fmt.Errorf("SetStateVariables error: %w", err)
+	}
+	pairs, err := buildStateVariableValuePairs(values)
+	if err != nil {
+		return nil, fmt.Errorf("SetStateVariables build error: %w", err)
+	}
+	xmlRequest, err := setStateVariablesSoapBuild(a.ServiceType, avTransportUDN, serviceType, serviceID, string(pairs))
 	if err != nil {
-		return fmt.Errorf("SetNextAVTransportMedia Failed to read response: %w", err)
+		return nil, fmt.Errorf("SetStateVariables build error: %w", err)
 	}
 
-	var resp setNextAVTransportURIResponse
-	if err := xml.Unmarshal(respBody, &resp); err != nil {
-		return fmt.Errorf("SetNextAVTransportMedia Failed to unmarshal response: %w", err)
+	r, err := a.soapCall(ctx, "SetStateVariables", xmlRequest)
+	if err != nil {
+		return nil, fmt.Errorf("SetStateVariables error: %w", err)
 	}
 
-	return nil
+	return splitCSV(r["StateVariableList"]), nil
 }
 
-// GetTransportInfo
-func (a *Client) GetTransportInfo(ctx context.Context) (TransportInfo, error) {
-	xmlbuilder, err := getTransportInfoSoapBuild()
+// Next advances to the next track of the current media
+func (a *Client) Next(ctx context.Context) error {
+	xml, err := nextSoapBuild(a.ServiceType)
 	if err != nil {
-		return TransportInfo{}, fmt.Errorf("GetTransportInfo build error: %w", err)
+		return fmt.Errorf("Next build error: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", a.controlURL, bytes.NewReader(xmlbuilder))
-	if err != nil {
-		return TransportInfo{}, fmt.Errorf("GetTransportInfo POST error: %w", err)
+	if _, err := a.soapCall(ctx, "Next", xml); err != nil {
+		return fmt.Errorf("Next error: %w", err)
 	}
-	req.Header = utils.BuildRequestHeader(`"urn:schemas-upnp-org:service:AVTransport:1#GetTransportInfo"`)
+	return nil
+}
 
-	res, err := a.HTTPClient.Do(req)
+// Previous goes back to the previous track of the current media
+func (a *Client) Previous(ctx context.Context) error {
+	xml, err := previousSoapBuild(a.ServiceType)
 	if err != nil {
-		return TransportInfo{}, fmt.Errorf("GetTransportInfo Do POST error: %w", err)
+		return fmt.Errorf("Previous build error: %w", err)
 	}
-	defer res.Body.Close()
 
-	resBytes, err := io.ReadAll(res.Body)
+	if _, err := a.soapCall(ctx, "Previous", xml); err != nil {
+		return fmt.Errorf("Previous error: %w", err)
+	}
+	return nil
+}
+
+// Record starts recording the current media on devices that support it
+func (a *Client) Record(ctx context.Context) error {
+	xml, err := recordSoapBuild(a.ServiceType)
 	if err != nil {
-		return TransportInfo{}, fmt.Errorf("GetTransportInfo Failed to read response: %w", err)
+		return fmt.Errorf("Record build error: %w", err)
 	}
 
-	var respTransportInfo getTransportInfoResponse
+	if _, err := a.soapCall(ctx, "Record", xml); err != nil {
+		return fmt.Errorf("Record error: %w", err)
+	}
+	return nil
+}
 
-	if err := xml.Unmarshal(resBytes, &respTransportInfo); err != nil {
-		return TransportInfo{}, fmt.Errorf("GetTransportInfo Failed to unmarshal response: %w", err)
+// GetDeviceCapabilities returns the storage media and record quality modes the device supports
+func (a *Client) GetDeviceCapabilities(ctx context.Context) (DeviceCapabilities, error) {
+	xmlRequest, err := getDeviceCapabilitiesSoapBuild(a.ServiceType)
+	if err != nil {
+		return DeviceCapabilities{}, fmt.Errorf("GetDeviceCapabilities build error: %w", err)
 	}
 
-	r := respTransportInfo.Body.GetTransportInfoResponse
-	info := TransportInfo{
-		Status: r.CurrentTransportStatus,
-		State:  r.CurrentTransportState,
-		Speed:  r.CurrentSpeed,
+	r, err := a.soapCall(ctx, "GetDeviceCapabilities", xmlRequest)
+	if err != nil {
+		return DeviceCapabilities{}, fmt.Errorf("GetDeviceCapabilities error: %w", err)
 	}
 
-	return info, nil
+	return DeviceCapabilities{
+		PlayMedia:       splitCSV(r["PlayMedia"]),
+		RecMedia:        splitCSV(r["RecMedia"]),
+		RecQualityModes: splitCSV(r["RecQualityModes"]),
+	}, nil
 }
 
-func (a *Client) GetPositionInfo(ctx context.Context) (PositionInfo, error) {
-	xmlRequest, err := getPositionInfoSoapBuild()
+// GetTransportSettings returns the current play mode and record quality mode
+func (a *Client) GetTransportSettings(ctx context.Context) (TransportSettings, error) {
+	xmlRequest, err := getTransportSettingsSoapBuild(a.ServiceType)
 	if err != nil {
-		return PositionInfo{}, fmt.Errorf("GetPositionInfo build error: %w", err)
+		return TransportSettings{}, fmt.Errorf("GetTransportSettings build error: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", a.controlURL, bytes.NewReader(xmlRequest))
+	r, err := a.soapCall(ctx, "GetTransportSettings", xmlRequest)
 	if err != nil {
-		return PositionInfo{}, fmt.Errorf("GetPositionInfo POST error: %w", err)
+		return TransportSettings{}, fmt.Errorf("GetTransportSettings error: %w", err)
 	}
-	req.Header = utils.BuildRequestHeader(`"urn:schemas-upnp-org:service:AVTransport:1#GetPositionInfo"`)
 
-	res, err := a.HTTPClient.Do(req)
+	return TransportSettings{
+		PlayMode:       PlayMode(strings.TrimSpace(r["PlayMode"])),
+		RecQualityMode: r["RecQualityMode"],
+	}, nil
+}
+
+// SetPlayMode sets the play mode, e.g. to shuffle or repeat.
+// Returns ErrPlayModeNotSupported (wrapped) if the device does not support the mode.
+func (a *Client) SetPlayMode(ctx context.Context, mode PlayMode) error {
+	xml, err := setPlayModeSoapBuild(a.ServiceType, string(mode))
 	if err != nil {
-		return PositionInfo{}, fmt.Errorf("GetPositionInfo Do POST error: %w", err)
+		return fmt.Errorf("SetPlayMode build error: %w", err)
 	}
-	defer res.Body.Close()
 
-	resBytes, err := io.ReadAll(res.Body)
+	if _, err := a.soapCall(ctx, "SetPlayMode", xml); err != nil {
+		return fmt.Errorf("SetPlayMode error: %w", err)
+	}
+	return nil
+}
+
+// GetCurrentTransportActions returns the actions that can currently be invoked,
+// e.g. "Play", "Stop", "Pause", "Seek", "Next", "Previous"
+func (a *Client) GetCurrentTransportActions(ctx context.Context) ([]string, error) {
+	xmlRequest, err := getCurrentTransportActionsSoapBuild(a.ServiceType)
 	if err != nil {
-		return PositionInfo{}, fmt.Errorf("GetPositionInfo Failed to read response: %w", err)
+		return nil, fmt.Errorf("GetCurrentTransportActions build error: %w", err)
 	}
 
-	var respPositionInfo getPositionInfoResponse
-	if err := xml.Unmarshal(resBytes, &respPositionInfo); err != nil {
-		return PositionInfo{}, fmt.Errorf("GetPositionInfo Failed to unmarshal response: %w", err)
+	r, err := a.soapCall(ctx, "GetCurrentTransportActions", xmlRequest)
+	if err != nil {
+		return nil, fmt.Errorf("GetCurrentTransportActions error: %w", err)
 	}
 
-	r := respPositionInfo.Body.GetPositionInfoResponse
-	dur, err := utils.ParseDuration(r.TrackDuration)
-	rel, err2 := utils.ParseDuration(r.RelTime)
-	if err2 != nil && err == nil {
-		err = err2
+	return splitCSV(r["Actions"]), nil
+}
+
+// SetRecordQualityMode sets the quality mode used for recording
+func (a *Client) SetRecordQualityMode(ctx context.Context, qualityMode string) error {
+	xml, err := setRecordQualityModeSoapBuild(a.ServiceType, qualityMode)
+	if err != nil {
+		return fmt.Errorf("SetRecordQualityMode build error: %w", err)
 	}
 
-	return PositionInfo{Duration: dur, RelTime: rel}, err
+	if _, err := a.soapCall(ctx, "SetRecordQualityMode", xml); err != nil {
+		return fmt.Errorf("SetRecordQualityMode error: %w", err)
+	}
+	return nil
 }
 
 func (a *Client) playPauseStopSoapCall(ctx context.Context, action string) error {
@@ -242,38 +557,93 @@ func (a *Client) playPauseStopSoapCall(ctx context.Context, action string) error
 
 	switch action {
 	case "Play":
-		xml, err = playSoapBuild()
+		xml, err = playSoapBuild(a.ServiceType)
 	case "Stop":
-		xml, err = stopSoapBuild()
+		xml, err = stopSoapBuild(a.ServiceType)
 	case "Pause":
-		xml, err = pauseSoapBuild()
+		xml, err = pauseSoapBuild(a.ServiceType)
 	}
 	if err != nil {
 		return fmt.Errorf("AVTransportActionSoapCall action error: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", a.controlURL, bytes.NewReader(xml))
-	if err != nil {
-		return fmt.Errorf("AVTransportActionSoapCall POST error: %w", err)
+	if _, err := a.soapCall(ctx, action, xml); err != nil {
+		return fmt.Errorf("AVTransportActionSoapCall error: %w", err)
 	}
 
-	req.Header = utils.BuildRequestHeader(`"urn:schemas-upnp-org:service:AVTransport:1#` + action + `"`)
+	return nil
+}
+
+// soapCall invokes the action on the AVTransport service, returning the output arguments.
+func (a *Client) soapCall(ctx context.Context, action string, envelope []byte) (map[string]string, error) {
+	sc := soap.Client{HTTPClient: a.HTTPClient}
+	return sc.InvokeEnvelope(ctx, a.controlURL, a.ServiceType, action, envelope)
+}
+
+// parseMediaInfo parses the output arguments of GetMediaInfo and GetMediaInfo_Ext
+func parseMediaInfo(r map[string]string) MediaInfo {
+	info := MediaInfo{
+		CurrentURI:   r["CurrentURI"],
+		NextURI:      r["NextURI"],
+		PlayMedium:   r["PlayMedium"],
+		RecordMedium: r["RecordMedium"],
+		WriteStatus:  r["WriteStatus"],
+	}
+	info.NrTracks, _ = strconv.Atoi(strings.TrimSpace(r["NrTracks"]))
+	info.MediaDuration, _ = parsePositionDuration(r["MediaDuration"])
+	// metadata is informational; devices often report none or invalid DIDL-Lite
+	info.CurrentURIMetaData, _ = parseURIMetadata(r["CurrentURIMetaData"], r["CurrentURI"])
+	info.NextURIMetaData, _ = parseURIMetadata(r["NextURIMetaData"], r["NextURI"])
+	return info
+}
 
-	res, err := a.HTTPClient.Do(req)
+// parsePositionDuration parses a clock time value,
+// returning UnknownDuration for NOT_IMPLEMENTED or empty values
+func parsePositionDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "NOT_IMPLEMENTED" {
+		return UnknownDuration, nil
+	}
+	d, err := utils.ParseDuration(s)
 	if err != nil {
-		return fmt.Errorf("AVTransportActionSoapCall Do POST error: %w", err)
+		return UnknownDuration, err
 	}
-	defer res.Body.Close()
+	return d, nil
+}
 
-	_, err = io.ReadAll(res.Body)
-	if err != nil {
-		return fmt.Errorf("AVTransportActionSoapCall Failed to read response: %w", err)
+// parsePositionTime is like parsePositionDuration,
+// but also reports values that fail to parse as UnknownDuration
+func parsePositionTime(s string) time.Duration {
+	d, _ := parsePositionDuration(s)
+	return d
+}
+
+// parsePositionCount parses a counter position,
+// returning UnknownCount for NOT_IMPLEMENTED or invalid values
+func parsePositionCount(s string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	// the spec reserves the max i4 value for NOT_IMPLEMENTED
+	if err != nil || n == math.MaxInt32 {
+		return UnknownCount
 	}
+	return n
+}
 
-	_, err = json.Marshal(res.Header)
-	if err != nil {
-		return fmt.Errorf("AVTransportActionSoapCall Response Marshaling error: %w", err)
+// splitCSV splits a comma-separated list state variable value
+func splitCSV(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
 	}
+	return list
+}
 
+// requireVersion returns ErrUnsupportedAction if the service is older than version v
+func (a *Client) requireVersion(v int) error {
+	if services.Version(a.ServiceType) < v {
+		return ErrUnsupportedAction
+	}
 	return nil
 }
@@ -0,0 +1,172 @@
+package avtransport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supersonic-app/go-upnpcast/services"
+)
+
+func TestGetPositionInfo(t *testing.T) {
+	tt := []struct {
+		name     string
+		response string
+		want     PositionInfo
+	}{
+		{
+			`GetPositionInfo Test #1`,
+			`<Track>2</Track><TrackDuration>0:03:25.500</TrackDuration><TrackMetaData></TrackMetaData><TrackURI>http://192.168.88.250:3500/a.mp3</TrackURI><RelTime>0:01:00</RelTime><AbsTime>NOT_IMPLEMENTED</AbsTime><RelCount>2147483647</RelCount><AbsCount>12</AbsCount>`,
+			PositionInfo{
+				Track:    2,
+				Duration: 3*time.Minute + 25500*time.Millisecond,
+				TrackURI: "http://192.168.88.250:3500/a.mp3",
+				RelTime:  time.Minute,
+				AbsTime:  UnknownDuration,
+				RelCount: UnknownCount,
+				AbsCount: 12,
+			},
+		},
+		{
+			`GetPositionInfo Test #2`,
+			`<Track>0</Track><TrackDuration>NOT_IMPLEMENTED</TrackDuration><TrackMetaData>NOT_IMPLEMENTED</TrackMetaData><TrackURI></TrackURI><RelTime>NOT_IMPLEMENTED</RelTime><AbsTime>NOT_IMPLEMENTED</AbsTime><RelCount>NOT_IMPLEMENTED</RelCount><AbsCount>NOT_IMPLEMENTED</AbsCount>`,
+			PositionInfo{
+				Duration: UnknownDuration,
+				RelTime:  UnknownDuration,
+				AbsTime:  UnknownDuration,
+				RelCount: UnknownCount,
+				AbsCount: UnknownCount,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:GetPositionInfoResponse xmlns:u="urn:schemas-upnp-org:service:AVTransport:1">` + tc.response + `</u:GetPositionInfoResponse></s:Body></s:Envelope>`))
+			}))
+			defer srv.Close()
+
+			out, err := NewClient(srv.URL, "").GetPositionInfo(context.Background())
+			if err != nil {
+				t.Fatalf("%s: Failed to call GetPositionInfo due to %s", tc.name, err.Error())
+			}
+			if out != tc.want {
+				t.Fatalf("%s: got: %+v, want: %+v.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
+
+func TestGetMediaInfo(t *testing.T) {
+	tt := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{
+			`GetMediaInfo Test #1`,
+			`<NrTracks>1</NrTracks><MediaDuration>0:03:25</MediaDuration><CurrentURI>http://192.168.88.250:3500/a.mp3</CurrentURI>` +
+				`<CurrentURIMetaData>&lt;DIDL-Lite xmlns=&quot;urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/&quot; xmlns:dc=&quot;http://purl.org/dc/elements/1.1/&quot;&gt;&lt;item&gt;&lt;dc:title&gt;foo&lt;/dc:title&gt;&lt;/item&gt;&lt;/DIDL-Lite&gt;</CurrentURIMetaData>` +
+				`<NextURI></NextURI><NextURIMetaData></NextURIMetaData><PlayMedium>NETWORK</PlayMedium><RecordMedium>NOT_IMPLEMENTED</RecordMedium><WriteStatus>NOT_IMPLEMENTED</WriteStatus>`,
+			`1 3m25s http://192.168.88.250:3500/a.mp3 foo http://192.168.88.250:3500/a.mp3 <nil> NETWORK`,
+		},
+		{
+			`GetMediaInfo Test #2`,
+			`<NrTracks>0</NrTracks><MediaDuration>NOT_IMPLEMENTED</MediaDuration><CurrentURI></CurrentURI><CurrentURIMetaData>NOT_IMPLEMENTED</CurrentURIMetaData>` +
+				`<NextURI>NOT_IMPLEMENTED</NextURI><NextURIMetaData>NOT_IMPLEMENTED</NextURIMetaData><PlayMedium>NONE</PlayMedium><RecordMedium>NOT_IMPLEMENTED</RecordMedium><WriteStatus>NOT_IMPLEMENTED</WriteStatus>`,
+			`0 -1ns  <nil> <nil> NONE`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:GetMediaInfoResponse xmlns:u="urn:schemas-upnp-org:service:AVTransport:1">` + tc.response + `</u:GetMediaInfoResponse></s:Body></s:Envelope>`))
+			}))
+			defer srv.Close()
+
+			out, err := NewClient(srv.URL, "").GetMediaInfo(context.Background())
+			if err != nil {
+				t.Fatalf("%s: Failed to call GetMediaInfo due to %s", tc.name, err.Error())
+			}
+			current := "<nil>"
+			if m := out.CurrentURIMetaData; m != nil {
+				current = m.Title + " " + m.URL
+			}
+			got := fmt.Sprintf("%d %s %s %s %s %s", out.NrTracks, out.MediaDuration, out.CurrentURI, current, deref(out.NextURIMetaData), out.PlayMedium)
+			if got != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGetStateVariables(t *testing.T) {
+	const serviceType = "urn:schemas-upnp-org:service:AVTransport:2"
+	var soapAction, request string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		soapAction = r.Header.Get("SOAPAction")
+		b, _ := io.ReadAll(r.Body)
+		request = string(b)
+		w.Write([]byte(`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:GetStateVariablesResponse xmlns:u="` + serviceType + `"><StateVariableValuePairs>&lt;?xml version="1.0" encoding="UTF-8"?&gt;&lt;stateVariableValuePairs xmlns="urn:schemas-upnp-org:av:avs"&gt;&lt;stateVariable variableName="TransportState"&gt;PLAYING&lt;/stateVariable&gt;&lt;stateVariable variableName="CurrentPlayMode"&gt;NORMAL&lt;/stateVariable&gt;&lt;/stateVariableValuePairs&gt;</StateVariableValuePairs></u:GetStateVariablesResponse></s:Body></s:Envelope>`))
+	}))
+	defer srv.Close()
+
+	cli := NewClient(srv.URL, "")
+	cli.ServiceType = serviceType
+	out, err := cli.GetStateVariables(context.Background(), "TransportState", "CurrentPlayMode")
+	if err != nil {
+		t.Fatalf("Failed to call GetStateVariables due to %s", err.Error())
+	}
+
+	if want := `"` + serviceType + `#GetStateVariables"`; soapAction != want {
+		t.Fatalf("GetStateVariables: got SOAPAction: %s, want: %s.", soapAction, want)
+	}
+	if want := `<u:GetStateVariables xmlns:u="` + serviceType + `"><InstanceID>0</InstanceID><StateVariableList>TransportState,CurrentPlayMode</StateVariableList></u:GetStateVariables>`; !strings.Contains(request, want) {
+		t.Fatalf("GetStateVariables: got request: %s, want: %s.", request, want)
+	}
+	if want := fmt.Sprint(map[string]string{"TransportState": "PLAYING", "CurrentPlayMode": "NORMAL"}); fmt.Sprint(out) != want {
+		t.Fatalf("GetStateVariables: got: %v, want: %v.", out, want)
+	}
+}
+
+func TestUnsupportedAction(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	cli := NewClient(srv.URL, "")
+	cli.ServiceType = services.AVTransport
+
+	tt := []struct {
+		name string
+		call func() error
+	}{
+		{`GetMediaInfoExt`, func() error { _, err := cli.GetMediaInfoExt(context.Background()); return err }},
+		{`GetStateVariables`, func() error { _, err := cli.GetStateVariables(context.Background(), "*"); return err }},
+		{`SetStateVariables`, func() error {
+			_, err := cli.SetStateVariables(context.Background(), "", "", "", map[string]string{"CurrentPlayMode": "NORMAL"})
+			return err
+		}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.call(); !errors.Is(err, ErrUnsupportedAction) {
+				t.Fatalf("%s: got error: %v, want: %v.", tc.name, err, ErrUnsupportedAction)
+			}
+			if requests != 0 {
+				t.Fatalf("%s: got requests: %d, want: 0.", tc.name, requests)
+			}
+		})
+	}
+}
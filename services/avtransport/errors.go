@@ -0,0 +1,29 @@
+package avtransport
+
+import (
+	"github.com/supersonic-app/go-upnpcast/services"
+	"github.com/supersonic-app/go-upnpcast/soap"
+)
+
+// AVTransport service errors, returned wrapped by Client methods
+// when the device responds to an action with a UPnP error.
+var (
+	ErrTransitionNotAvailable        = &soap.UPnPError{Code: 701, Description: "Transition not available", ServiceType: services.AVTransport}
+	ErrNoContents                    = &soap.UPnPError{Code: 702, Description: "No contents", ServiceType: services.AVTransport}
+	ErrReadError                     = &soap.UPnPError{Code: 703, Description: "Read error", ServiceType: services.AVTransport}
+	ErrFormatNotSupportedForPlayback = &soap.UPnPError{Code: 704, Description: "Format not supported for playback", ServiceType: services.AVTransport}
+	ErrTransportIsLocked             = &soap.UPnPError{Code: 705, Description: "Transport is locked", ServiceType: services.AVTransport}
+	ErrWriteError                    = &soap.UPnPError{Code: 706, Description: "Write error", ServiceType: services.AVTransport}
+	ErrMediaIsProtected              = &soap.UPnPError{Code: 707, Description: "Media is protected or not writeable", ServiceType: services.AVTransport}
+	ErrFormatNotSupportedForRecord   = &soap.UPnPError{Code: 708, Description: "Format not supported for recording", ServiceType: services.AVTransport}
+	ErrMediaIsFull                   = &soap.UPnPError{Code: 709, Description: "Media is full", ServiceType: services.AVTransport}
+	ErrSeekModeNotSupported          = &soap.UPnPError{Code: 710, Description: "Seek mode not supported", ServiceType: services.AVTransport}
+	ErrIllegalSeekTarget             = &soap.UPnPError{Code: 711, Description: "Illegal seek target", ServiceType: services.AVTransport}
+	ErrPlayModeNotSupported          = &soap.UPnPError{Code: 712, Description: "Play mode not supported", ServiceType: services.AVTransport}
+	ErrRecordQualityNotSupported     = &soap.UPnPError{Code: 713, Description: "Record quality not supported", ServiceType: services.AVTransport}
+	ErrIllegalMIMEType               = &soap.UPnPError{Code: 714, Description: "Illegal MIME-type", ServiceType: services.AVTransport}
+	ErrContentBusy                   = &soap.UPnPError{Code: 715, Description: "Content 'BUSY'", ServiceType: services.AVTransport}
+	ErrResourceNotFound              = &soap.UPnPError{Code: 716, Description: "Resource not found", ServiceType: services.AVTransport}
+	ErrPlaySpeedNotSupported         = &soap.UPnPError{Code: 717, Description: "Play speed not supported", ServiceType: services.AVTransport}
+	ErrInvalidInstanceID             = &soap.UPnPError{Code: 718, Description: "Invalid InstanceID", ServiceType: services.AVTransport}
+)
@@ -0,0 +1,224 @@
+package avtransport
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/supersonic-app/go-upnpcast/internal/gena"
+	"github.com/supersonic-app/go-upnpcast/internal/utils"
+)
+
+var ErrAlreadySubscribed = errors.New("already subscribed to events")
+
+// Event is a change of AVTransport state variables, parsed from a LastChange event.
+// Only the fields for state variables included in the event are set.
+type Event struct {
+	// AVTransport instance the event applies to. Always 0 for typical renderers.
+	InstanceID int
+
+	TransportState       *TransportState
+	TransportStatus      *TransportStatus
+	TransportPlaySpeed   *PlaySpeed
+	CurrentPlayMode      *PlayMode
+	NumberOfTracks       *int
+	CurrentTrack         *int
+	CurrentTrackDuration *time.Duration
+	CurrentMediaDuration *time.Duration
+	CurrentTrackURI      *string
+	AVTransportURI       *string
+	NextAVTransportURI   *string
+
+	// Metadata decoded from DIDL-Lite, nil if the event has none or it is not valid.
+	// The raw XML is in Variables.
+	CurrentTrackMetaData       *MediaItem
+	AVTransportURIMetaData     *MediaItem
+	NextAVTransportURIMetaData *MediaItem
+
+	// Comma-separated list of actions that can currently be invoked
+	CurrentTransportActions *string
+
+	// All state variables included in the event by name,
+	// including those without a typed field.
+	Variables map[string]string
+}
+
+type lastChangeEvent struct {
+	XMLName   xml.Name `xml:"Event"`
+	Instances []struct {
+		Val       string `xml:"val,attr"`
+		Variables []struct {
+			XMLName xml.Name
+			Val     string `xml:"val,attr"`
+		} `xml:",any"`
+	} `xml:"InstanceID"`
+}
+
+type eventSubscription struct {
+	sub    *gena.Subscription
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// SubscribeEvents subscribes to the AVTransport service's LastChange events.
+// Events are delivered on the returned channel until ctx is cancelled or Close is called,
+// after which the subscription is cancelled and the channel is closed.
+// The subscription is automatically renewed before it expires.
+func (a *Client) SubscribeEvents(ctx context.Context) (<-chan Event, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.events != nil {
+		return nil, ErrAlreadySubscribed
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	events := make(chan Event, 16)
+	var sendMu sync.Mutex
+	closed := false
+
+	sub, err := gena.Subscribe(ctx, a.HTTPClient, a.eventSubURL, gena.DefaultTimeout, func(vars map[string]string) {
+		lastChange, ok := vars["LastChange"]
+		if !ok {
+			return
+		}
+		evs, err := parseLastChange(lastChange)
+		if err != nil {
+			return
+		}
+
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		for _, e := range evs {
+			if closed {
+				return
+			}
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("SubscribeEvents error: %w", err)
+	}
+
+	es := &eventSubscription{sub: sub, cancel: cancel, done: make(chan struct{})}
+	a.events = es
+	go func() {
+		<-ctx.Done()
+		sub.Close()
+
+		sendMu.Lock()
+		closed = true
+		close(events)
+		sendMu.Unlock()
+
+		a.mu.Lock()
+		if a.events == es {
+			a.events = nil
+		}
+		a.mu.Unlock()
+		close(es.done)
+	}()
+
+	return events, nil
+}
+
+// Close cancels the event subscription started by SubscribeEvents, if any.
+func (a *Client) Close() error {
+	a.mu.Lock()
+	es := a.events
+	a.mu.Unlock()
+
+	if es != nil {
+		es.cancel()
+		<-es.done
+	}
+	return nil
+}
+
+func parseLastChange(lastChange string) ([]Event, error) {
+	var lc lastChangeEvent
+	if err := xml.Unmarshal([]byte(lastChange), &lc); err != nil {
+		return nil, fmt.Errorf("parseLastChange unmarshal error: %w", err)
+	}
+
+	events := make([]Event, 0, len(lc.Instances))
+	for _, inst := range lc.Instances {
+		id, _ := strconv.Atoi(inst.Val)
+		e := Event{InstanceID: id, Variables: make(map[string]string, len(inst.Variables))}
+		for _, v := range inst.Variables {
+			val := v.Val
+			e.Variables[v.XMLName.Local] = val
+			switch v.XMLName.Local {
+			case "TransportState":
+				st := ParseTransportState(val)
+				e.TransportState = &st
+			case "TransportStatus":
+				st := ParseTransportStatus(val)
+				e.TransportStatus = &st
+			case "TransportPlaySpeed":
+				if speed, err := ParsePlaySpeed(val); err == nil {
+					e.TransportPlaySpeed = &speed
+				}
+			case "CurrentPlayMode":
+				mode := PlayMode(strings.TrimSpace(val))
+				e.CurrentPlayMode = &mode
+			case "NumberOfTracks":
+				e.NumberOfTracks = parseEventInt(val)
+			case "CurrentTrack":
+				e.CurrentTrack = parseEventInt(val)
+			case "CurrentTrackDuration":
+				e.CurrentTrackDuration = parseEventDuration(val)
+			case "CurrentMediaDuration":
+				e.CurrentMediaDuration = parseEventDuration(val)
+			case "CurrentTrackURI":
+				e.CurrentTrackURI = &val
+			case "AVTransportURI":
+				e.AVTransportURI = &val
+			case "NextAVTransportURI":
+				e.NextAVTransportURI = &val
+			case "CurrentTransportActions":
+				e.CurrentTransportActions = &val
+			}
+		}
+		e.CurrentTrackMetaData = parseEventMetadata(e.Variables, "CurrentTrackMetaData", "CurrentTrackURI")
+		e.AVTransportURIMetaData = parseEventMetadata(e.Variables, "AVTransportURIMetaData", "AVTransportURI")
+		e.NextAVTransportURIMetaData = parseEventMetadata(e.Variables, "NextAVTransportURIMetaData", "NextAVTransportURI")
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+func parseEventInt(val string) *int {
+	i, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil {
+		return nil
+	}
+	return &i
+}
+
+func parseEventDuration(val string) *time.Duration {
+	d, err := utils.ParseDuration(strings.TrimSpace(val))
+	if err != nil {
+		return nil
+	}
+	return &d
+}
+
+// parseEventMetadata decodes the metadata variable, with the URL of the URI variable if the event has it
+func parseEventMetadata(vars map[string]string, metadataName, uriName string) *MediaItem {
+	metadata, ok := vars[metadataName]
+	if !ok {
+		return nil
+	}
+	media, _ := parseURIMetadata(metadata, vars[uriName])
+	return media
+}
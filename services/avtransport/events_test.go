@@ -0,0 +1,130 @@
+package avtransport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseLastChange(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			`parseLastChange Test #1`,
+			`<Event xmlns="urn:schemas-upnp-org:metadata-1-0/AVT/"><InstanceID val="0"><TransportState val="PLAYING"/><CurrentTrackURI val="http://192.168.88.250:3500/a.mp3"/><CurrentTrack val="2"/><CurrentTrackDuration val="0:03:25"/></InstanceID></Event>`,
+			`0 PLAYING http://192.168.88.250:3500/a.mp3 2 3m25s <nil>`,
+		},
+		{
+			`parseLastChange Test #2`,
+			`<Event xmlns="urn:schemas-upnp-org:metadata-1-0/AVT/"><InstanceID val="0"><TransportState val="STOPPED"/><CurrentTrackMetaData val="&lt;DIDL-Lite&gt;&lt;/DIDL-Lite&gt;"/></InstanceID></Event>`,
+			`0 STOPPED <nil> <nil> <nil> <nil>`,
+		},
+		{
+			`parseLastChange Test #3`,
+			`<Event xmlns="urn:schemas-upnp-org:metadata-1-0/AVT/"><InstanceID val="0"><TransportState val="PLAYING"/><CurrentTrackURI val="http://192.168.88.250:3500/a.mp3"/>` +
+				`<CurrentTrackMetaData val="&lt;DIDL-Lite xmlns=&quot;urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/&quot; xmlns:dc=&quot;http://purl.org/dc/elements/1.1/&quot;&gt;&lt;item&gt;&lt;dc:title&gt;foo&lt;/dc:title&gt;&lt;/item&gt;&lt;/DIDL-Lite&gt;"/></InstanceID></Event>`,
+			`0 PLAYING http://192.168.88.250:3500/a.mp3 <nil> <nil> foo http://192.168.88.250:3500/a.mp3`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := parseLastChange(tc.input)
+			if err != nil {
+				t.Fatalf("%s: Failed to call parseLastChange due to %s", tc.name, err.Error())
+			}
+			if len(out) != 1 {
+				t.Fatalf("%s: got %d events, want 1.", tc.name, len(out))
+			}
+			e := out[0]
+			got := fmt.Sprintf("%d %s %s %s %s", e.InstanceID, deref(e.TransportState), deref(e.CurrentTrackURI), deref(e.CurrentTrack), deref(e.CurrentTrackDuration))
+			if m := e.CurrentTrackMetaData; m != nil {
+				got += fmt.Sprintf(" %s %s", m.Title, m.URL)
+			} else {
+				got += " <nil>"
+			}
+			if got != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, got, tc.want)
+			}
+		})
+	}
+}
+
+func deref[T any](p *T) string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprint(*p)
+}
+
+func TestSubscribeEvents(t *testing.T) {
+	var mu sync.Mutex
+	var callback string
+	unsubscribed := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "SUBSCRIBE":
+			mu.Lock()
+			callback = strings.Trim(r.Header.Get("CALLBACK"), "<>")
+			mu.Unlock()
+			w.Header().Set("SID", "uuid:test-sid")
+			w.Header().Set("TIMEOUT", "Second-1800")
+		case "UNSUBSCRIBE":
+			if r.Header.Get("SID") == "uuid:test-sid" {
+				close(unsubscribed)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL+"/control", srv.URL+"/event")
+	events, err := c.SubscribeEvents(context.Background())
+	if err != nil {
+		t.Fatalf("SubscribeEvents: %s", err.Error())
+	}
+
+	lastChange := `&lt;Event xmlns="urn:schemas-upnp-org:metadata-1-0/AVT/"&gt;&lt;InstanceID val="0"&gt;&lt;TransportState val="PAUSED_PLAYBACK"/&gt;&lt;/InstanceID&gt;&lt;/Event&gt;`
+	body := `<?xml version="1.0"?><e:propertyset xmlns:e="urn:schemas-upnp-org:event-1-0"><e:property><LastChange>` + lastChange + `</LastChange></e:property></e:propertyset>`
+	mu.Lock()
+	req, _ := http.NewRequest("NOTIFY", callback, strings.NewReader(body))
+	mu.Unlock()
+	req.Header.Set("NT", "upnp:event")
+	req.Header.Set("NTS", "upnp:propchange")
+	req.Header.Set("SID", "uuid:test-sid")
+	req.Header.Set("SEQ", "0")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("NOTIFY: %s", err.Error())
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("NOTIFY: got status %s, want 200 OK.", res.Status)
+	}
+
+	select {
+	case e := <-events:
+		if got := deref(e.TransportState); got != "PAUSED_PLAYBACK" {
+			t.Fatalf("SubscribeEvents: got: %s, want: PAUSED_PLAYBACK.", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("SubscribeEvents: timed out waiting for event")
+	}
+
+	c.Close()
+	select {
+	case <-unsubscribed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Close: timed out waiting for UNSUBSCRIBE")
+	}
+	if _, ok := <-events; ok {
+		t.Fatalf("Close: events channel not closed")
+	}
+}
@@ -5,138 +5,14 @@ import (
 	"encoding/xml"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 
 	"github.com/supersonic-app/go-upnpcast/internal/utils"
+	"github.com/supersonic-app/go-upnpcast/services"
+	"github.com/supersonic-app/go-upnpcast/soap"
 )
 
-type playEnvelope struct {
-	XMLName  xml.Name `xml:"s:Envelope"`
-	Schema   string   `xml:"xmlns:s,attr"`
-	Encoding string   `xml:"s:encodingStyle,attr"`
-	PlayBody playBody `xml:"s:Body"`
-}
-
-type playBody struct {
-	XMLName    xml.Name   `xml:"s:Body"`
-	PlayAction playAction `xml:"u:Play"`
-}
-
-type playAction struct {
-	XMLName     xml.Name `xml:"u:Play"`
-	AVTransport string   `xml:"xmlns:u,attr"`
-	InstanceID  string
-	Speed       string
-}
-
-type pauseEnvelope struct {
-	XMLName   xml.Name  `xml:"s:Envelope"`
-	Schema    string    `xml:"xmlns:s,attr"`
-	Encoding  string    `xml:"s:encodingStyle,attr"`
-	PauseBody pauseBody `xml:"s:Body"`
-}
-
-type pauseBody struct {
-	XMLName     xml.Name    `xml:"s:Body"`
-	PauseAction pauseAction `xml:"u:Pause"`
-}
-
-type pauseAction struct {
-	XMLName     xml.Name `xml:"u:Pause"`
-	AVTransport string   `xml:"xmlns:u,attr"`
-	InstanceID  string
-	Speed       string
-}
-
-type stopEnvelope struct {
-	XMLName  xml.Name `xml:"s:Envelope"`
-	Schema   string   `xml:"xmlns:s,attr"`
-	Encoding string   `xml:"s:encodingStyle,attr"`
-	StopBody stopBody `xml:"s:Body"`
-}
-
-type stopBody struct {
-	XMLName    xml.Name   `xml:"s:Body"`
-	StopAction stopAction `xml:"u:Stop"`
-}
-
-type stopAction struct {
-	XMLName     xml.Name `xml:"u:Stop"`
-	AVTransport string   `xml:"xmlns:u,attr"`
-	InstanceID  string
-	Speed       string
-}
-
-type seekEnvelope struct {
-	XMLName  xml.Name `xml:"s:Envelope"`
-	Schema   string   `xml:"xmlns:s,attr"`
-	Encoding string   `xml:"s:encodingStyle,attr"`
-	SeekBody seekBody `xml:"s:Body"`
-}
-
-type seekBody struct {
-	XMLName    xml.Name   `xml:"s:Body"`
-	SeekAction seekAction `xml:"u:Seek"`
-}
-
-type seekAction struct {
-	XMLName     xml.Name `xml:"u:Seek"`
-	AVTransport string   `xml:"xmlns:u,attr"`
-	InstanceID  string
-	Unit        string
-	Target      string
-}
-
-type setAVTransportEnvelope struct {
-	XMLName  xml.Name           `xml:"s:Envelope"`
-	Schema   string             `xml:"xmlns:s,attr"`
-	Encoding string             `xml:"s:encodingStyle,attr"`
-	Body     setAVTransportBody `xml:"s:Body"`
-}
-
-type setAVTransportBody struct {
-	XMLName           xml.Name          `xml:"s:Body"`
-	SetAVTransportURI setAVTransportURI `xml:"u:SetAVTransportURI"`
-}
-
-type setAVTransportURI struct {
-	XMLName            xml.Name `xml:"u:SetAVTransportURI"`
-	AVTransport        string   `xml:"xmlns:u,attr"`
-	InstanceID         string
-	CurrentURI         string
-	CurrentURIMetaData currentURIMetaData `xml:"CurrentURIMetaData"`
-}
-
-type currentURIMetaData struct {
-	XMLName xml.Name `xml:"CurrentURIMetaData"`
-	Value   []byte   `xml:",chardata"`
-}
-
-type setNextAVTransportEnvelope struct {
-	XMLName  xml.Name               `xml:"s:Envelope"`
-	Schema   string                 `xml:"xmlns:s,attr"`
-	Encoding string                 `xml:"s:encodingStyle,attr"`
-	Body     setNextAVTransportBody `xml:"s:Body"`
-}
-
-type setNextAVTransportBody struct {
-	XMLName               xml.Name              `xml:"s:Body"`
-	SetNextAVTransportURI setNextAVTransportURI `xml:"u:SetNextAVTransportURI"`
-}
-
-type setNextAVTransportURI struct {
-	XMLName         xml.Name `xml:"u:SetNextAVTransportURI"`
-	AVTransport     string   `xml:"xmlns:u,attr"`
-	InstanceID      string
-	NextURI         string
-	NextURIMetaData nextURIMetaData `xml:"NextURIMetaData"`
-}
-
-type nextURIMetaData struct {
-	XMLName xml.Name `xml:"NextURIMetaData"`
-	Value   []byte   `xml:",chardata"`
-}
-
 type didLLite struct {
 	XMLName      xml.Name     `xml:"DIDL-Lite"`
 	SchemaDIDL   string       `xml:"xmlns,attr"`
@@ -177,60 +53,6 @@ type secCaptionInfoEx struct {
 	Value   string   `xml:",chardata"`
 }
 
-type getMediaInfoEnvelope struct {
-	XMLName          xml.Name         `xml:"s:Envelope"`
-	Schema           string           `xml:"xmlns:s,attr"`
-	Encoding         string           `xml:"s:encodingStyle,attr"`
-	GetMediaInfoBody getMediaInfoBody `xml:"s:Body"`
-}
-
-type getMediaInfoBody struct {
-	XMLName            xml.Name           `xml:"s:Body"`
-	GetMediaInfoAction getMediaInfoAction `xml:"u:GetMediaInfo"`
-}
-
-type getMediaInfoAction struct {
-	XMLName     xml.Name `xml:"u:GetMediaInfo"`
-	AVTransport string   `xml:"xmlns:u,attr"`
-	InstanceID  string
-}
-
-type getTransportInfoEnvelope struct {
-	XMLName              xml.Name             `xml:"s:Envelope"`
-	Schema               string               `xml:"xmlns:s,attr"`
-	Encoding             string               `xml:"s:encodingStyle,attr"`
-	GetTransportInfoBody getTransportInfoBody `xml:"s:Body"`
-}
-
-type getTransportInfoBody struct {
-	XMLName                xml.Name               `xml:"s:Body"`
-	GetTransportInfoAction getTransportInfoAction `xml:"u:GetTransportInfo"`
-}
-
-type getTransportInfoAction struct {
-	XMLName     xml.Name `xml:"u:GetTransportInfo"`
-	AVTransport string   `xml:"xmlns:u,attr"`
-	InstanceID  string
-}
-
-type getPositionInfoEnvelope struct {
-	XMLName             xml.Name            `xml:"s:Envelope"`
-	Schema              string              `xml:"xmlns:s,attr"`
-	Encoding            string              `xml:"s:encodingStyle,attr"`
-	GetPositionInfoBody getPositionInfoBody `xml:"s:Body"`
-}
-
-type getPositionInfoBody struct {
-	XMLName               xml.Name              `xml:"s:Body"`
-	GetPositionInfoAction getPositionInfoAction `xml:"u:GetPositionInfo"`
-}
-
-type getPositionInfoAction struct {
-	XMLName     xml.Name `xml:"u:GetPositionInfo"`
-	AVTransport string   `xml:"xmlns:u,attr"`
-	InstanceID  string
-}
-
 func buildURIMetadataPayload(media *MediaItem) ([]byte, error) {
 	mediaTypeSlice := strings.Split(media.ContentType, "/")
 	seekflag := "00"
@@ -253,6 +75,11 @@ func buildURIMetadataPayload(media *MediaItem) ([]byte, error) {
 		class = "object.item.videoItem.movie"
 	}
 
+	protocolInfo := media.ProtocolInfo
+	if protocolInfo == "" {
+		protocolInfo = fmt.Sprintf("http-get:*:%s:%s", media.ContentType, contentFeatures)
+	}
+
 	var didl didLLiteItem
 	resNodeData := []resNode{}
 	//duration, _ := utils.DurationForMedia(media.URL)
@@ -260,7 +87,7 @@ func buildURIMetadataPayload(media *MediaItem) ([]byte, error) {
 	if media.Duration == 0 {
 		resNodeData = append(resNodeData, resNode{
 			XMLName:      xml.Name{},
-			ProtocolInfo: fmt.Sprintf("http-get:*:%s:%s", media.ContentType, contentFeatures),
+			ProtocolInfo: protocolInfo,
 			Value:        media.URL,
 		})
 	} else {
@@ -271,7 +98,7 @@ func buildURIMetadataPayload(media *MediaItem) ([]byte, error) {
 		resNodeData = append(resNodeData, resNode{
 			XMLName:      xml.Name{},
 			Duration:     duration,
-			ProtocolInfo: fmt.Sprintf("http-get:*:%s:%s", media.ContentType, contentFeatures),
+			ProtocolInfo: protocolInfo,
 			Value:        media.URL,
 		})
 	}
@@ -334,241 +161,205 @@ func buildURIMetadataPayload(media *MediaItem) ([]byte, error) {
 	return a, nil
 }
 
-func setAVTransportSoapBuild(media *MediaItem) ([]byte, error) {
+// parseURIMetadata decodes DIDL-Lite metadata, as built by buildURIMetadataPayload,
+// back into a MediaItem. Returns nil if there is no metadata.
+func parseURIMetadata(metadata, uri string) (*MediaItem, error) {
+	metadata = strings.TrimSpace(metadata)
+	if metadata == "" || metadata == "NOT_IMPLEMENTED" {
+		return nil, nil
+	}
+
+	var didl didlLiteResponse
+	if err := xml.Unmarshal([]byte(metadata), &didl); err != nil {
+		return nil, fmt.Errorf("parseURIMetadata unmarshal error: %w", err)
+	}
+	if len(didl.Items) == 0 {
+		return nil, nil
+	}
+
+	item := didl.Items[0]
+	media := &MediaItem{
+		URL:          uri,
+		Title:        item.Title,
+		SubtitlesURL: strings.TrimSpace(item.SecCaptionInfo),
+	}
+	for _, res := range item.Res {
+		parts := strings.SplitN(res.ProtocolInfo, ":", 4)
+		if len(parts) == 4 && strings.HasPrefix(parts[2], "text/") {
+			if media.SubtitlesURL == "" {
+				media.SubtitlesURL = strings.TrimSpace(res.Value)
+			}
+			continue
+		}
+		if media.ContentType != "" {
+			// already found the media res node
+			continue
+		}
+		if u := strings.TrimSpace(res.Value); u != "" {
+			media.URL = u
+		}
+		media.ProtocolInfo = res.ProtocolInfo
+		if len(parts) == 4 {
+			media.ContentType = parts[2]
+			media.Seekable = dlnaOperationSeekable(parts[3])
+		}
+		if res.Duration != "" {
+			media.Duration, _ = utils.ParseDuration(res.Duration)
+		}
+	}
+	return media, nil
+}
+
+// dlnaOperationSeekable returns whether the DLNA.ORG_OP parameter
+// in a protocolInfo's additional info allows time or range seeking
+func dlnaOperationSeekable(additionalInfo string) bool {
+	for _, param := range strings.Split(additionalInfo, ";") {
+		if op, ok := strings.CutPrefix(param, "DLNA.ORG_OP="); ok {
+			return strings.Contains(op, "1")
+		}
+	}
+	return false
+}
+
+func setAVTransportSoapBuild(serviceType services.Type, media *MediaItem) ([]byte, error) {
 	meta, err := buildURIMetadataPayload(media)
 	if err != nil {
 		return nil, err
 	}
-	d := setAVTransportEnvelope{
-		XMLName:  xml.Name{},
-		Schema:   "http://schemas.xmlsoap.org/soap/envelope/",
-		Encoding: "http://schemas.xmlsoap.org/soap/encoding/",
-		Body: setAVTransportBody{
-			XMLName: xml.Name{},
-			SetAVTransportURI: setAVTransportURI{
-				XMLName:     xml.Name{},
-				AVTransport: "urn:schemas-upnp-org:service:AVTransport:1",
-				InstanceID:  "0",
-				CurrentURI:  media.URL,
-				CurrentURIMetaData: currentURIMetaData{
-					XMLName: xml.Name{},
-					Value:   meta,
-				},
-			},
-		},
-	}
-	xmlStart := []byte(`<?xml version="1.0" encoding="utf-8"?>`)
-	b, err := xml.Marshal(d)
+	b, err := soap.BuildEnvelope(serviceType, "SetAVTransportURI",
+		soap.Arg{Name: "InstanceID", Value: "0"},
+		soap.Arg{Name: "CurrentURI", Value: media.URL},
+		soap.Arg{Name: "CurrentURIMetaData", Value: string(meta)},
+	)
 	if err != nil {
-		return nil, fmt.Errorf("setAVTransportSoapBuild #2 Marshal error: %w", err)
+		return nil, fmt.Errorf("setAVTransportSoapBuild error: %w", err)
 	}
 
-	// Samsung TV hack.
-	b = bytes.ReplaceAll(b, []byte("&#34;"), []byte(`"`))
-	b = bytes.ReplaceAll(b, []byte("&amp;"), []byte("&"))
-
-	return append(xmlStart, b...), nil
+	return samsungHack(b), nil
 }
 
-func setNextAVTransportSoapBuild(media *MediaItem) ([]byte, error) {
+func setNextAVTransportSoapBuild(serviceType services.Type, media *MediaItem) ([]byte, error) {
 	meta, err := buildURIMetadataPayload(media)
 	if err != nil {
 		return nil, err
 	}
+	b, err := soap.BuildEnvelope(serviceType, "SetNextAVTransportURI",
+		soap.Arg{Name: "InstanceID", Value: "0"},
+		soap.Arg{Name: "NextURI", Value: media.URL},
+		soap.Arg{Name: "NextURIMetaData", Value: string(meta)},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("setNextAVTransportSoapBuild error: %w", err)
+	}
 
-	d := setNextAVTransportEnvelope{
-		XMLName:  xml.Name{},
-		Schema:   "http://schemas.xmlsoap.org/soap/envelope/",
-		Encoding: "http://schemas.xmlsoap.org/soap/encoding/",
-		Body: setNextAVTransportBody{
-			XMLName: xml.Name{},
-			SetNextAVTransportURI: setNextAVTransportURI{
-				XMLName:     xml.Name{},
-				AVTransport: "urn:schemas-upnp-org:service:AVTransport:1",
-				InstanceID:  "0",
-				NextURI:     media.URL,
-				NextURIMetaData: nextURIMetaData{
-					XMLName: xml.Name{},
-					Value:   meta,
-				},
-			},
-		},
+	return samsungHack(b), nil
+}
+
+// buildStateVariableValuePairs builds the document passed to SetStateVariables,
+// ordering the variables by name
+func buildStateVariableValuePairs(values map[string]string) ([]byte, error) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
 	}
-	xmlStart := []byte(`<?xml version="1.0" encoding="utf-8"?>`)
-	b, err := xml.Marshal(d)
+	sort.Strings(names)
+
+	var pairs stateVariableValuePairs
+	for _, name := range names {
+		pairs.StateVariables = append(pairs.StateVariables, stateVariableValuePair{Name: name, Value: values[name]})
+	}
+
+	b, err := xml.Marshal(pairs)
 	if err != nil {
-		return nil, fmt.Errorf("setNextAVTransportSoapBuild #2 Marshal error: %w", err)
+		return nil, fmt.Errorf("buildStateVariableValuePairs Marshal error: %w", err)
 	}
+	return append([]byte(xml.Header), b...), nil
+}
 
-	// Samsung TV hack.
+// Samsung TV hack.
+// Samsung TVs fail to parse the metadata if its quotes and ampersands are escaped.
+func samsungHack(b []byte) []byte {
 	b = bytes.ReplaceAll(b, []byte("&#34;"), []byte(`"`))
 	b = bytes.ReplaceAll(b, []byte("&amp;"), []byte("&"))
+	return b
+}
 
-	return append(xmlStart, b...), nil
+func playSoapBuild(serviceType services.Type) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "Play", soap.Arg{Name: "Speed", Value: "1"})
 }
 
-func playSoapBuild() ([]byte, error) {
-	d := playEnvelope{
-		XMLName:  xml.Name{},
-		Schema:   "http://schemas.xmlsoap.org/soap/envelope/",
-		Encoding: "http://schemas.xmlsoap.org/soap/encoding/",
-		PlayBody: playBody{
-			XMLName: xml.Name{},
-			PlayAction: playAction{
-				XMLName:     xml.Name{},
-				AVTransport: "urn:schemas-upnp-org:service:AVTransport:1",
-				InstanceID:  "0",
-				Speed:       "1",
-			},
-		},
-	}
-	xmlStart := []byte(`<?xml version="1.0" encoding="utf-8"?>`)
-	b, err := xml.Marshal(d)
-	if err != nil {
-		return nil, fmt.Errorf("playSoapBuild Marshal error: %w", err)
-	}
+func stopSoapBuild(serviceType services.Type) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "Stop", soap.Arg{Name: "Speed", Value: "1"})
+}
 
-	return append(xmlStart, b...), nil
+func pauseSoapBuild(serviceType services.Type) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "Pause", soap.Arg{Name: "Speed", Value: "1"})
 }
 
-func stopSoapBuild() ([]byte, error) {
-	d := stopEnvelope{
-		XMLName:  xml.Name{},
-		Schema:   "http://schemas.xmlsoap.org/soap/envelope/",
-		Encoding: "http://schemas.xmlsoap.org/soap/encoding/",
-		StopBody: stopBody{
-			XMLName: xml.Name{},
-			StopAction: stopAction{
-				XMLName:     xml.Name{},
-				AVTransport: "urn:schemas-upnp-org:service:AVTransport:1",
-				InstanceID:  "0",
-				Speed:       "1",
-			},
-		},
-	}
-	xmlStart := []byte(`<?xml version="1.0" encoding="utf-8"?>`)
-	b, err := xml.Marshal(d)
-	if err != nil {
-		return nil, fmt.Errorf("stopSoapBuild Marshal error: %w", err)
-	}
+func getMediaInfoSoapBuild(serviceType services.Type) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "GetMediaInfo")
+}
 
-	return append(xmlStart, b...), nil
+func getMediaInfoExtSoapBuild(serviceType services.Type) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "GetMediaInfo_Ext")
 }
 
-func pauseSoapBuild() ([]byte, error) {
-	d := pauseEnvelope{
-		XMLName:  xml.Name{},
-		Schema:   "http://schemas.xmlsoap.org/soap/envelope/",
-		Encoding: "http://schemas.xmlsoap.org/soap/encoding/",
-		PauseBody: pauseBody{
-			XMLName: xml.Name{},
-			PauseAction: pauseAction{
-				XMLName:     xml.Name{},
-				AVTransport: "urn:schemas-upnp-org:service:AVTransport:1",
-				InstanceID:  "0",
-				Speed:       "1",
-			},
-		},
-	}
-	xmlStart := []byte(`<?xml version="1.0" encoding="utf-8"?>`)
-	b, err := xml.Marshal(d)
-	if err != nil {
-		return nil, fmt.Errorf("pauseSoapBuild Marshal error: %w", err)
-	}
+func getStateVariablesSoapBuild(serviceType services.Type, stateVariableList string) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "GetStateVariables", soap.Arg{Name: "StateVariableList", Value: stateVariableList})
+}
 
-	return append(xmlStart, b...), nil
+func setStateVariablesSoapBuild(serviceType services.Type, avTransportUDN, targetServiceType, serviceID, pairs string) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "SetStateVariables",
+		soap.Arg{Name: "AVTransportUDN", Value: avTransportUDN},
+		soap.Arg{Name: "ServiceType", Value: targetServiceType},
+		soap.Arg{Name: "ServiceId", Value: serviceID},
+		soap.Arg{Name: "StateVariableValuePairs", Value: pairs},
+	)
 }
 
-func getMediaInfoSoapBuild() ([]byte, error) {
-	d := getMediaInfoEnvelope{
-		XMLName:  xml.Name{},
-		Schema:   "http://schemas.xmlsoap.org/soap/envelope/",
-		Encoding: "http://schemas.xmlsoap.org/soap/encoding/",
-		GetMediaInfoBody: getMediaInfoBody{
-			XMLName: xml.Name{},
-			GetMediaInfoAction: getMediaInfoAction{
-				XMLName:     xml.Name{},
-				AVTransport: "urn:schemas-upnp-org:service:AVTransport:1",
-				InstanceID:  "0",
-			},
-		},
-	}
-	xmlStart := []byte(`<?xml version="1.0" encoding="utf-8"?>`)
-	b, err := xml.Marshal(d)
-	if err != nil {
-		return nil, fmt.Errorf("getMediaInfoSoapBuild Marshal error: %w", err)
-	}
+func getTransportInfoSoapBuild(serviceType services.Type) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "GetTransportInfo")
+}
 
-	return append(xmlStart, b...), nil
+func getPositionInfoSoapBuild(serviceType services.Type) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "GetPositionInfo")
 }
 
-func getTransportInfoSoapBuild() ([]byte, error) {
-	d := getTransportInfoEnvelope{
-		XMLName:  xml.Name{},
-		Schema:   "http://schemas.xmlsoap.org/soap/envelope/",
-		Encoding: "http://schemas.xmlsoap.org/soap/encoding/",
-		GetTransportInfoBody: getTransportInfoBody{
-			XMLName: xml.Name{},
-			GetTransportInfoAction: getTransportInfoAction{
-				XMLName:     xml.Name{},
-				AVTransport: "urn:schemas-upnp-org:service:AVTransport:1",
-				InstanceID:  "0",
-			},
-		},
-	}
-	xmlStart := []byte(`<?xml version="1.0" encoding="utf-8"?>`)
-	b, err := xml.Marshal(d)
-	if err != nil {
-		return nil, fmt.Errorf("getTransportInfoSoapBuild Marshal error: %w", err)
-	}
+func seekSoapBuild(serviceType services.Type, unit, target string) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "Seek",
+		soap.Arg{Name: "Unit", Value: unit},
+		soap.Arg{Name: "Target", Value: target},
+	)
+}
 
-	return append(xmlStart, b...), nil
+func nextSoapBuild(serviceType services.Type) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "Next")
 }
 
-func getPositionInfoSoapBuild() ([]byte, error) {
-	d := getPositionInfoEnvelope{
-		XMLName:  xml.Name{},
-		Schema:   "http://schemas.xmlsoap.org/soap/envelope/",
-		Encoding: "http://schemas.xmlsoap.org/soap/encoding/",
-		GetPositionInfoBody: getPositionInfoBody{
-			XMLName: xml.Name{},
-			GetPositionInfoAction: getPositionInfoAction{
-				XMLName:     xml.Name{},
-				AVTransport: "urn:schemas-upnp-org:service:AVTransport:1",
-				InstanceID:  "0",
-			},
-		},
-	}
-	xmlStart := []byte(`<?xml version="1.0" encoding="utf-8"?>`)
-	b, err := xml.Marshal(d)
-	if err != nil {
-		return nil, fmt.Errorf("getPositionInfoSoapBuild Marshal error: %w", err)
-	}
+func previousSoapBuild(serviceType services.Type) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "Previous")
+}
 
-	return append(xmlStart, b...), nil
+func recordSoapBuild(serviceType services.Type) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "Record")
 }
 
-func seekSoapBuild(reltime string) ([]byte, error) {
-	d := seekEnvelope{
-		XMLName:  xml.Name{},
-		Schema:   "http://schemas.xmlsoap.org/soap/envelope/",
-		Encoding: "http://schemas.xmlsoap.org/soap/encoding/",
-		SeekBody: seekBody{
-			XMLName: xml.Name{},
-			SeekAction: seekAction{
-				XMLName:     xml.Name{},
-				AVTransport: "urn:schemas-upnp-org:service:AVTransport:1",
-				InstanceID:  "0",
-				Unit:        "REL_TIME",
-				Target:      reltime,
-			},
-		},
-	}
-	xmlStart := []byte(`<?xml version="1.0" encoding="utf-8"?>`)
-	b, err := xml.Marshal(d)
-	if err != nil {
-		return nil, fmt.Errorf("seekSoapBuild Marshal error: %w", err)
-	}
+func getDeviceCapabilitiesSoapBuild(serviceType services.Type) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "GetDeviceCapabilities")
+}
+
+func getTransportSettingsSoapBuild(serviceType services.Type) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "GetTransportSettings")
+}
+
+func getCurrentTransportActionsSoapBuild(serviceType services.Type) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "GetCurrentTransportActions")
+}
+
+func setPlayModeSoapBuild(serviceType services.Type, playMode string) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "SetPlayMode", soap.Arg{Name: "NewPlayMode", Value: playMode})
+}
 
-	return append(xmlStart, b...), nil
+func setRecordQualityModeSoapBuild(serviceType services.Type, qualityMode string) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "SetRecordQualityMode", soap.Arg{Name: "NewRecordQualityMode", Value: qualityMode})
 }
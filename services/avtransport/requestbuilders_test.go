@@ -1,9 +1,12 @@
 package avtransport
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/supersonic-app/go-upnpcast/internal/utils"
+	"github.com/supersonic-app/go-upnpcast/services"
 )
 
 func TestSetAVTransportSoapBuild(t *testing.T) {
@@ -38,7 +41,7 @@ func TestSetAVTransportSoapBuild(t *testing.T) {
 
 			want := `<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:SetAVTransportURI xmlns:u="urn:schemas-upnp-org:service:AVTransport:1"><InstanceID>0</InstanceID><CurrentURI>http://192.168.88.250:3500/video%20%26%20%27example%27.mp4</CurrentURI><CurrentURIMetaData>&lt;DIDL-Lite xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:sec="http://www.sec.co.kr/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/"&gt;&lt;item id="1" parentID="0" restricted="1"&gt;&lt;sec:CaptionInfo sec:type="srt"&gt;http://192.168.88.250:3500/video_example.srt&lt;/sec:CaptionInfo&gt;&lt;sec:CaptionInfoEx sec:type="srt"&gt;http://192.168.88.250:3500/video_example.srt&lt;/sec:CaptionInfoEx&gt;&lt;dc:title&gt;foo&lt;/dc:title&gt;&lt;upnp:class&gt;object.item.videoItem.movie&lt;/upnp:class&gt;&lt;res protocolInfo="http-get:*:video/mp4:` + contentFeatures + `"&gt;http://192.168.88.250:3500/video%20%26%20%27example%27.mp4&lt;/res&gt;&lt;res protocolInfo="http-get:*:text/srt:*"&gt;http://192.168.88.250:3500/video_example.srt&lt;/res&gt;&lt;/item&gt;&lt;/DIDL-Lite&gt;</CurrentURIMetaData></u:SetAVTransportURI></s:Body></s:Envelope>`
 
-			out, err := setAVTransportSoapBuild(tc.media)
+			out, err := setAVTransportSoapBuild(services.AVTransport, tc.media)
 			if err != nil {
 				t.Fatalf("%s: Failed to call setAVTransportSoapBuild due to %s", tc.name, err.Error())
 			}
@@ -81,7 +84,7 @@ func TestSetNextAVTransportSoapBuild(t *testing.T) {
 
 			want := `<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:SetNextAVTransportURI xmlns:u="urn:schemas-upnp-org:service:AVTransport:1"><InstanceID>0</InstanceID><NextURI>http://192.168.88.250:3500/video%20%26%20%27example%27.mp4</NextURI><NextURIMetaData>&lt;DIDL-Lite xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:sec="http://www.sec.co.kr/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/"&gt;&lt;item id="1" parentID="0" restricted="1"&gt;&lt;sec:CaptionInfo sec:type="srt"&gt;http://192.168.88.250:3500/video_example.srt&lt;/sec:CaptionInfo&gt;&lt;sec:CaptionInfoEx sec:type="srt"&gt;http://192.168.88.250:3500/video_example.srt&lt;/sec:CaptionInfoEx&gt;&lt;dc:title&gt;foo&lt;/dc:title&gt;&lt;upnp:class&gt;object.item.videoItem.movie&lt;/upnp:class&gt;&lt;res protocolInfo="http-get:*:video/mp4:` + contentFeatures + `"&gt;http://192.168.88.250:3500/video%20%26%20%27example%27.mp4&lt;/res&gt;&lt;res protocolInfo="http-get:*:text/srt:*"&gt;http://192.168.88.250:3500/video_example.srt&lt;/res&gt;&lt;/item&gt;&lt;/DIDL-Lite&gt;</NextURIMetaData></u:SetNextAVTransportURI></s:Body></s:Envelope>`
 
-			out, err := setNextAVTransportSoapBuild(tc.tv)
+			out, err := setNextAVTransportSoapBuild(services.AVTransport, tc.tv)
 			if err != nil {
 				t.Fatalf("%s: Failed to call setNextAVTransportSoapBuild due to %s", tc.name, err.Error())
 			}
@@ -105,7 +108,7 @@ func TestPlaySoapBuild(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			out, err := playSoapBuild()
+			out, err := playSoapBuild(services.AVTransport)
 			if err != nil {
 				t.Fatalf("%s: Failed to call playSoapBuild due to %s", tc.name, err.Error())
 			}
@@ -129,7 +132,7 @@ func TestStopSoapBuild(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			out, err := stopSoapBuild()
+			out, err := stopSoapBuild(services.AVTransport)
 			if err != nil {
 				t.Fatalf("%s: Failed to call stopSoapBuild due to %s", tc.name, err.Error())
 			}
@@ -153,7 +156,7 @@ func TestPauseSoapBuild(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			out, err := pauseSoapBuild()
+			out, err := pauseSoapBuild(services.AVTransport)
 			if err != nil {
 				t.Fatalf("%s: Failed to call pauseSoapBuild due to %s", tc.name, err.Error())
 			}
@@ -177,7 +180,7 @@ func TestGetTransportInfoSoapBuild(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			out, err := getTransportInfoSoapBuild()
+			out, err := getTransportInfoSoapBuild(services.AVTransport)
 			if err != nil {
 				t.Fatalf("%s: Failed to call getTransportInfoSoapBuild due to %s", tc.name, err.Error())
 			}
@@ -201,7 +204,7 @@ func TestGetPositionInfoSoapBuild(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			out, err := getPositionInfoSoapBuild()
+			out, err := getPositionInfoSoapBuild(services.AVTransport)
 			if err != nil {
 				t.Fatalf("%s: Failed to call getPositionInfoSoapBuild due to %s", tc.name, err.Error())
 			}
@@ -215,19 +218,49 @@ func TestGetPositionInfoSoapBuild(t *testing.T) {
 func TestSeekSoapBuild(t *testing.T) {
 	tt := []struct {
 		name   string
-		target string
+		target SeekTarget
 		want   string
 	}{
 		{
 			`seekSoapBuildTest #1`,
-			"00:01:30",
+			SeekRelTime(90 * time.Second),
 			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:Seek xmlns:u="urn:schemas-upnp-org:service:AVTransport:1"><InstanceID>0</InstanceID><Unit>REL_TIME</Unit><Target>00:01:30</Target></u:Seek></s:Body></s:Envelope>`,
 		},
+		{
+			`seekSoapBuildTest #2`,
+			SeekRelTime(90*time.Second + 250*time.Millisecond),
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:Seek xmlns:u="urn:schemas-upnp-org:service:AVTransport:1"><InstanceID>0</InstanceID><Unit>REL_TIME</Unit><Target>00:01:30.250</Target></u:Seek></s:Body></s:Envelope>`,
+		},
+		{
+			`seekSoapBuildTest #3`,
+			SeekAbsTime(2*time.Hour + 5*time.Second),
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:Seek xmlns:u="urn:schemas-upnp-org:service:AVTransport:1"><InstanceID>0</InstanceID><Unit>ABS_TIME</Unit><Target>02:00:05</Target></u:Seek></s:Body></s:Envelope>`,
+		},
+		{
+			`seekSoapBuildTest #4`,
+			SeekTrack(3),
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:Seek xmlns:u="urn:schemas-upnp-org:service:AVTransport:1"><InstanceID>0</InstanceID><Unit>TRACK_NR</Unit><Target>3</Target></u:Seek></s:Body></s:Envelope>`,
+		},
+		{
+			`seekSoapBuildTest #5`,
+			SeekAbsCount(1000),
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:Seek xmlns:u="urn:schemas-upnp-org:service:AVTransport:1"><InstanceID>0</InstanceID><Unit>ABS_COUNT</Unit><Target>1000</Target></u:Seek></s:Body></s:Envelope>`,
+		},
+		{
+			`seekSoapBuildTest #6`,
+			SeekRelCount(20),
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:Seek xmlns:u="urn:schemas-upnp-org:service:AVTransport:1"><InstanceID>0</InstanceID><Unit>REL_COUNT</Unit><Target>20</Target></u:Seek></s:Body></s:Envelope>`,
+		},
+		{
+			`seekSoapBuildTest #7`,
+			SeekRelByte(1048576),
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:Seek xmlns:u="urn:schemas-upnp-org:service:AVTransport:1"><InstanceID>0</InstanceID><Unit>X_DLNA_REL_BYTE</Unit><Target>1048576</Target></u:Seek></s:Body></s:Envelope>`,
+		},
 	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			out, err := seekSoapBuild(tc.target)
+			out, err := seekSoapBuild(services.AVTransport, string(tc.target.Unit), tc.target.Target)
 			if err != nil {
 				t.Fatalf("%s: Failed to call seekSoapBuild due to %s", tc.name, err.Error())
 			}
@@ -237,3 +270,178 @@ func TestSeekSoapBuild(t *testing.T) {
 		})
 	}
 }
+
+func TestGetMediaInfoSoapBuild(t *testing.T) {
+	tt := []struct {
+		name string
+		want string
+	}{
+		{
+			`getMediaInfoSoapBuild Test #1`,
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:GetMediaInfo xmlns:u="urn:schemas-upnp-org:service:AVTransport:1"><InstanceID>0</InstanceID></u:GetMediaInfo></s:Body></s:Envelope>`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := getMediaInfoSoapBuild(services.AVTransport)
+			if err != nil {
+				t.Fatalf("%s: Failed to call getMediaInfoSoapBuild due to %s", tc.name, err.Error())
+			}
+			if string(out) != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
+
+func TestNextSoapBuild(t *testing.T) {
+	tt := []struct {
+		name string
+		want string
+	}{
+		{
+			`nextSoapBuild Test #1`,
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:Next xmlns:u="urn:schemas-upnp-org:service:AVTransport:1"><InstanceID>0</InstanceID></u:Next></s:Body></s:Envelope>`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := nextSoapBuild(services.AVTransport)
+			if err != nil {
+				t.Fatalf("%s: Failed to call nextSoapBuild due to %s", tc.name, err.Error())
+			}
+			if string(out) != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetPlayModeSoapBuild(t *testing.T) {
+	tt := []struct {
+		name string
+		mode PlayMode
+		want string
+	}{
+		{
+			`setPlayModeSoapBuild Test #1`,
+			PlayModeRepeatAll,
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:SetPlayMode xmlns:u="urn:schemas-upnp-org:service:AVTransport:1"><InstanceID>0</InstanceID><NewPlayMode>REPEAT_ALL</NewPlayMode></u:SetPlayMode></s:Body></s:Envelope>`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := setPlayModeSoapBuild(services.AVTransport, string(tc.mode))
+			if err != nil {
+				t.Fatalf("%s: Failed to call setPlayModeSoapBuild due to %s", tc.name, err.Error())
+			}
+			if string(out) != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
+
+func TestSplitCSV(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{`splitCSV Test #1`, "Play,Stop, Pause ,Seek", "[Play Stop Pause Seek]"},
+		{`splitCSV Test #2`, "", "[]"},
+		{`splitCSV Test #3`, "NETWORK,", "[NETWORK]"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := fmt.Sprint(splitCSV(tc.input)); got != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseURIMetadata(t *testing.T) {
+	tt := []struct {
+		name  string
+		media *MediaItem
+	}{
+		{
+			`parseURIMetadata Test #1`,
+			&MediaItem{
+				Title:        "foo",
+				URL:          `http://192.168.88.250:3500/video%20%26%20%27example%27.mp4`,
+				ContentType:  "video/mp4",
+				SubtitlesURL: "http://192.168.88.250:3500/video_example.srt",
+				Seekable:     true,
+				Duration:     90 * time.Second,
+			},
+		},
+		{
+			`parseURIMetadata Test #2`,
+			&MediaItem{
+				Title:        "bar",
+				URL:          `http://192.168.88.250:3500/audio.flac`,
+				ContentType:  "audio/flac",
+				ProtocolInfo: "http-get:*:audio/flac:*",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			meta, err := buildURIMetadataPayload(tc.media)
+			if err != nil {
+				t.Fatalf("%s: Failed to call buildURIMetadataPayload due to %s", tc.name, err.Error())
+			}
+			out, err := parseURIMetadata(string(meta), "")
+			if err != nil {
+				t.Fatalf("%s: Failed to call parseURIMetadata due to %s", tc.name, err.Error())
+			}
+
+			want := *tc.media
+			if want.ProtocolInfo == "" {
+				contentFeatures, _ := utils.BuildContentFeatures(want.ContentType, "01", false)
+				want.ProtocolInfo = "http-get:*:" + want.ContentType + ":" + contentFeatures
+			}
+			if *out != want {
+				t.Fatalf("%s: got: %+v, want: %+v.", tc.name, *out, want)
+			}
+		})
+	}
+}
+
+func TestSetStateVariablesSoapBuild(t *testing.T) {
+	tt := []struct {
+		name   string
+		values map[string]string
+		want   string
+	}{
+		{
+			`setStateVariablesSoapBuild Test #1`,
+			map[string]string{"TransportPlaySpeed": "1", "CurrentPlayMode": "SHUFFLE"},
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:SetStateVariables xmlns:u="urn:schemas-upnp-org:service:AVTransport:2"><InstanceID>0</InstanceID><AVTransportUDN>uuid:1</AVTransportUDN><ServiceType>urn:schemas-upnp-org:service:AVTransport:2</ServiceType><ServiceId>urn:upnp-org:serviceId:AVTransport</ServiceId><StateVariableValuePairs>&lt;?xml version=&#34;1.0&#34; encoding=&#34;UTF-8&#34;?&gt;&#xA;&lt;stateVariableValuePairs xmlns=&#34;urn:schemas-upnp-org:av:avs&#34;&gt;&lt;stateVariable variableName=&#34;CurrentPlayMode&#34;&gt;SHUFFLE&lt;/stateVariable&gt;&lt;stateVariable variableName=&#34;TransportPlaySpeed&#34;&gt;1&lt;/stateVariable&gt;&lt;/stateVariableValuePairs&gt;</StateVariableValuePairs></u:SetStateVariables></s:Body></s:Envelope>`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			const serviceType = "urn:schemas-upnp-org:service:AVTransport:2"
+			pairs, err := buildStateVariableValuePairs(tc.values)
+			if err != nil {
+				t.Fatalf("%s: Failed to call buildStateVariableValuePairs due to %s", tc.name, err.Error())
+			}
+			out, err := setStateVariablesSoapBuild(serviceType, "uuid:1", serviceType, "urn:upnp-org:serviceId:AVTransport", string(pairs))
+			if err != nil {
+				t.Fatalf("%s: Failed to call setStateVariablesSoapBuild due to %s", tc.name, err.Error())
+			}
+			if string(out) != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package avtransport
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var ErrInvalidPlaySpeed = errors.New("invalid play speed")
+
+// TransportState is the state of the transport, e.g. playing or stopped
+type TransportState string
+
+// Transport states defined by the AVTransport spec.
+// Vendor-specific states that cannot be mapped to one of these are kept as-is.
+const (
+	TransportStateStopped         TransportState = "STOPPED"
+	TransportStatePlaying         TransportState = "PLAYING"
+	TransportStateTransitioning   TransportState = "TRANSITIONING"
+	TransportStatePausedPlayback  TransportState = "PAUSED_PLAYBACK"
+	TransportStatePausedRecording TransportState = "PAUSED_RECORDING"
+	TransportStateRecording       TransportState = "RECORDING"
+	TransportStateNoMediaPresent  TransportState = "NO_MEDIA_PRESENT"
+)
+
+// non-standard state names sent by some renderers
+var transportStateAliases = map[string]TransportState{
+	"PLAY":      TransportStatePlaying,
+	"STOP":      TransportStateStopped,
+	"PAUSE":     TransportStatePausedPlayback,
+	"PAUSED":    TransportStatePausedPlayback,
+	"BUFFERING": TransportStateTransitioning,
+	"LOADING":   TransportStateTransitioning,
+	"NO_MEDIA":  TransportStateNoMediaPresent,
+}
+
+// ParseTransportState parses a transport state, tolerating differences
+// in casing and separators and some common vendor-specific names.
+func ParseTransportState(s string) TransportState {
+	norm := strings.ToUpper(strings.TrimSpace(s))
+	norm = strings.NewReplacer(" ", "_", "-", "_").Replace(norm)
+	switch st := TransportState(norm); st {
+	case TransportStateStopped, TransportStatePlaying, TransportStateTransitioning,
+		TransportStatePausedPlayback, TransportStatePausedRecording,
+		TransportStateRecording, TransportStateNoMediaPresent:
+		return st
+	}
+	if st, ok := transportStateAliases[norm]; ok {
+		return st
+	}
+	return TransportState(norm)
+}
+
+// IsPlaying returns true if the transport is playing
+func (s TransportState) IsPlaying() bool {
+	return s == TransportStatePlaying
+}
+
+// IsPaused returns true if playback or recording is paused
+func (s TransportState) IsPaused() bool {
+	return s == TransportStatePausedPlayback || s == TransportStatePausedRecording
+}
+
+// IsTransitioning returns true if the transport is changing state, e.g. buffering
+func (s TransportState) IsTransitioning() bool {
+	return s == TransportStateTransitioning
+}
+
+// IsIdle returns true if the transport is stopped or has no media loaded
+func (s TransportState) IsIdle() bool {
+	return s == TransportStateStopped || s == TransportStateNoMediaPresent
+}
+
+// TransportStatus indicates whether an asynchronous error occurred in the transport
+type TransportStatus string
+
+const (
+	TransportStatusOK            TransportStatus = "OK"
+	TransportStatusErrorOccurred TransportStatus = "ERROR_OCCURRED"
+)
+
+// ParseTransportStatus parses a transport status, tolerating differences in casing.
+// Vendor-specific statuses are kept as-is.
+func ParseTransportStatus(s string) TransportStatus {
+	norm := strings.ToUpper(strings.TrimSpace(s))
+	if norm == "ERROR" {
+		return TransportStatusErrorOccurred
+	}
+	return TransportStatus(norm)
+}
+
+// IsError returns true if the status reports an error
+func (s TransportStatus) IsError() bool {
+	return s == TransportStatusErrorOccurred
+}
+
+// PlaySpeed is a transport play speed as a rational number, e.g. 1, 1/2 or -2.
+// The zero value represents an unknown speed.
+type PlaySpeed struct {
+	Numerator   int
+	Denominator int
+}
+
+// NormalPlaySpeed is normal forward playback
+var NormalPlaySpeed = PlaySpeed{Numerator: 1, Denominator: 1}
+
+// ParsePlaySpeed parses a play speed as sent by the device,
+// e.g. "1", "-1/2", or the decimal form used by some renderers, e.g. "0.5".
+func ParsePlaySpeed(s string) (PlaySpeed, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || !r.Num().IsInt64() || !r.Denom().IsInt64() {
+		return PlaySpeed{}, fmt.Errorf("%w: %q", ErrInvalidPlaySpeed, s)
+	}
+	return PlaySpeed{Numerator: int(r.Num().Int64()), Denominator: int(r.Denom().Int64())}, nil
+}
+
+// Float64 returns the speed as a multiple of normal speed, or 0 if unknown
+func (p PlaySpeed) Float64() float64 {
+	if p.Denominator == 0 {
+		return 0
+	}
+	return float64(p.Numerator) / float64(p.Denominator)
+}
+
+// String returns the speed in the format used by the AVTransport spec,
+// or an empty string if unknown
+func (p PlaySpeed) String() string {
+	switch p.Denominator {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprint(p.Numerator)
+	}
+	return fmt.Sprintf("%d/%d", p.Numerator, p.Denominator)
+}
@@ -0,0 +1,55 @@
+package avtransport
+
+import (
+	"testing"
+)
+
+func TestParseTransportState(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		want  TransportState
+	}{
+		{`ParseTransportState Test #1`, "PLAYING", TransportStatePlaying},
+		{`ParseTransportState Test #2`, " Paused_Playback ", TransportStatePausedPlayback},
+		{`ParseTransportState Test #3`, "no media present", TransportStateNoMediaPresent},
+		{`ParseTransportState Test #4`, "PAUSED", TransportStatePausedPlayback},
+		{`ParseTransportState Test #5`, "Buffering", TransportStateTransitioning},
+		{`ParseTransportState Test #6`, "vendor_state", TransportState("VENDOR_STATE")},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if out := ParseTransportState(tc.input); out != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
+
+func TestParsePlaySpeed(t *testing.T) {
+	tt := []struct {
+		name    string
+		input   string
+		want    PlaySpeed
+		wantErr bool
+	}{
+		{`ParsePlaySpeed Test #1`, "1", NormalPlaySpeed, false},
+		{`ParsePlaySpeed Test #2`, "-1/2", PlaySpeed{Numerator: -1, Denominator: 2}, false},
+		{`ParsePlaySpeed Test #3`, "0.5", PlaySpeed{Numerator: 1, Denominator: 2}, false},
+		{`ParsePlaySpeed Test #4`, "1/0", PlaySpeed{}, true},
+		{`ParsePlaySpeed Test #5`, "", PlaySpeed{}, true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := ParsePlaySpeed(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("%s: got error: %v, want error: %t.", tc.name, err, tc.wantErr)
+			}
+			if out != tc.want {
+				t.Fatalf("%s: got: %+v, want: %+v.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
@@ -1,70 +1,49 @@
 package avtransport
 
-import "encoding/xml"
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
 
-type getPositionInfoResponse struct {
-	XMLName       xml.Name `xml:"Envelope"`
-	Text          string   `xml:",chardata"`
-	S             string   `xml:"s,attr"`
-	EncodingStyle string   `xml:"encodingStyle,attr"`
-	Body          struct {
-		Text                    string `xml:",chardata"`
-		GetPositionInfoResponse struct {
-			Text          string `xml:",chardata"`
-			U             string `xml:"u,attr"`
-			Track         string `xml:"Track"`
-			TrackDuration string `xml:"TrackDuration"`
-			TrackMetaData string `xml:"TrackMetaData"`
-			TrackURI      string `xml:"TrackURI"`
-			RelTime       string `xml:"RelTime"`
-			AbsTime       string `xml:"AbsTime"`
-			RelCount      string `xml:"RelCount"`
-			AbsCount      string `xml:"AbsCount"`
-		} `xml:"GetPositionInfoResponse"`
-	} `xml:"Body"`
+type didlLiteResponse struct {
+	XMLName xml.Name `xml:"DIDL-Lite"`
+	Items   []struct {
+		Title          string `xml:"title"`
+		Class          string `xml:"class"`
+		SecCaptionInfo string `xml:"CaptionInfo"`
+		Res            []struct {
+			ProtocolInfo string `xml:"protocolInfo,attr"`
+			Duration     string `xml:"duration,attr"`
+			Value        string `xml:",chardata"`
+		} `xml:"res"`
+	} `xml:"item"`
 }
 
-type getTransportInfoResponse struct {
-	XMLName       xml.Name `xml:"Envelope"`
-	Text          string   `xml:",chardata"`
-	S             string   `xml:"s,attr"`
-	EncodingStyle string   `xml:"encodingStyle,attr"`
-	Body          struct {
-		Text                     string `xml:",chardata"`
-		GetTransportInfoResponse struct {
-			Text                   string `xml:",chardata"`
-			U                      string `xml:"u,attr"`
-			CurrentTransportState  string `xml:"CurrentTransportState"`
-			CurrentTransportStatus string `xml:"CurrentTransportStatus"`
-			CurrentSpeed           string `xml:"CurrentSpeed"`
-		} `xml:"GetTransportInfoResponse"`
-	} `xml:"Body"`
+// stateVariableValuePairs is the document exchanged by GetStateVariables and SetStateVariables
+type stateVariableValuePairs struct {
+	XMLName        xml.Name                 `xml:"urn:schemas-upnp-org:av:avs stateVariableValuePairs"`
+	StateVariables []stateVariableValuePair `xml:"stateVariable"`
 }
 
-type setAVTransportURIResponse struct {
-	XMLName       xml.Name `xml:"Envelope"`
-	Text          string   `xml:",chardata"`
-	S             string   `xml:"s,attr"`
-	EncodingStyle string   `xml:"encodingStyle,attr"`
-	Body          struct {
-		Text                      string `xml:",chardata"`
-		SetAVTransportURIResponse struct {
-			Text string `xml:",chardata"`
-			U    string `xml:"u,attr"`
-		} `xml:"SetAVTransportURIResponse"`
-	} `xml:"Body"`
+type stateVariableValuePair struct {
+	Name  string `xml:"variableName,attr"`
+	Value string `xml:",chardata"`
 }
 
-type setNextAVTransportURIResponse struct {
-	XMLName       xml.Name `xml:"Envelope"`
-	Text          string   `xml:",chardata"`
-	S             string   `xml:"s,attr"`
-	EncodingStyle string   `xml:"encodingStyle,attr"`
-	Body          struct {
-		Text                      string `xml:",chardata"`
-		SetAVTransportURIResponse struct {
-			Text string `xml:",chardata"`
-			U    string `xml:"u,attr"`
-		} `xml:"SetNextAVTransportURIResponse"`
-	} `xml:"Body"`
+// parseStateVariableValuePairs returns the state variable values by name
+func parseStateVariableValuePairs(doc string) (map[string]string, error) {
+	values := make(map[string]string)
+	if strings.TrimSpace(doc) == "" {
+		return values, nil
+	}
+
+	var pairs stateVariableValuePairs
+	if err := xml.Unmarshal([]byte(doc), &pairs); err != nil {
+		return nil, fmt.Errorf("parseStateVariableValuePairs unmarshal error: %w", err)
+	}
+	for _, v := range pairs.StateVariables {
+		values[v.Name] = v.Value
+	}
+	return values, nil
 }
@@ -0,0 +1,134 @@
+package connectionmanager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supersonic-app/go-upnpcast/services"
+	"github.com/supersonic-app/go-upnpcast/soap"
+)
+
+// Client is a client to the device's ConnectionManager service
+type Client struct {
+	HTTPClient *http.Client
+
+	// Service type advertised by the device, used in SOAP requests.
+	// Defaults to ConnectionManager:1.
+	ServiceType services.Type
+
+	controlURL string
+}
+
+// SupportedProtocols is the information returned by GetProtocolInfo
+type SupportedProtocols struct {
+	// Protocols the device can send, usually empty for renderers
+	Source []ProtocolInfo
+
+	// Protocols the device can receive and render
+	Sink []ProtocolInfo
+}
+
+// ConnectionInfo is the information returned by GetCurrentConnectionInfo
+type ConnectionInfo struct {
+	RcsID                 int
+	AVTransportID         int
+	ProtocolInfo          ProtocolInfo
+	PeerConnectionManager string
+	PeerConnectionID      int
+
+	// "Input" or "Output"
+	Direction string
+
+	// "OK", "ContentFormatMismatch", "InsufficientBandwidth", "UnreliableChannel" or "Unknown"
+	Status string
+}
+
+// Should not be used directly. Use device.ConnectionManagerClient() instead.
+func NewClient(controlURL string) *Client {
+	return &Client{
+		HTTPClient:  &http.Client{Timeout: 10 * time.Second},
+		ServiceType: services.ConnectionManager,
+		controlURL:  controlURL,
+	}
+}
+
+// GetProtocolInfo returns the protocols and media formats the device supports
+func (c *Client) GetProtocolInfo(ctx context.Context) (SupportedProtocols, error) {
+	xmlbuilder, err := getProtocolInfoSoapBuild(c.ServiceType)
+	if err != nil {
+		return SupportedProtocols{}, fmt.Errorf("GetProtocolInfo build error: %w", err)
+	}
+
+	res, err := c.soapCall(ctx, "GetProtocolInfo", xmlbuilder)
+	if err != nil {
+		return SupportedProtocols{}, fmt.Errorf("GetProtocolInfo error: %w", err)
+	}
+
+	return SupportedProtocols{
+		Source: ParseProtocolInfoList(res["Source"]),
+		Sink:   ParseProtocolInfoList(res["Sink"]),
+	}, nil
+}
+
+// GetCurrentConnectionIDs returns the IDs of the device's current connections
+func (c *Client) GetCurrentConnectionIDs(ctx context.Context) ([]int, error) {
+	xmlbuilder, err := getCurrentConnectionIDsSoapBuild(c.ServiceType)
+	if err != nil {
+		return nil, fmt.Errorf("GetCurrentConnectionIDs build error: %w", err)
+	}
+
+	res, err := c.soapCall(ctx, "GetCurrentConnectionIDs", xmlbuilder)
+	if err != nil {
+		return nil, fmt.Errorf("GetCurrentConnectionIDs error: %w", err)
+	}
+
+	var ids []int
+	for _, s := range strings.Split(res["ConnectionIDs"], ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("GetCurrentConnectionIDs failed to parse connection ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// GetCurrentConnectionInfo returns information about the given connection
+func (c *Client) GetCurrentConnectionInfo(ctx context.Context, connectionID int) (ConnectionInfo, error) {
+	xmlbuilder, err := getCurrentConnectionInfoSoapBuild(c.ServiceType, strconv.Itoa(connectionID))
+	if err != nil {
+		return ConnectionInfo{}, fmt.Errorf("GetCurrentConnectionInfo build error: %w", err)
+	}
+
+	res, err := c.soapCall(ctx, "GetCurrentConnectionInfo", xmlbuilder)
+	if err != nil {
+		return ConnectionInfo{}, fmt.Errorf("GetCurrentConnectionInfo error: %w", err)
+	}
+
+	// protocolInfo may legitimately be empty if the connection is not yet set up
+	protocolInfo, _ := ParseProtocolInfo(res["ProtocolInfo"])
+	info := ConnectionInfo{
+		ProtocolInfo:          protocolInfo,
+		PeerConnectionManager: res["PeerConnectionManager"],
+		Direction:             res["Direction"],
+		Status:                res["Status"],
+	}
+	info.RcsID, _ = strconv.Atoi(strings.TrimSpace(res["RcsID"]))
+	info.AVTransportID, _ = strconv.Atoi(strings.TrimSpace(res["AVTransportID"]))
+	info.PeerConnectionID, _ = strconv.Atoi(strings.TrimSpace(res["PeerConnectionID"]))
+
+	return info, nil
+}
+
+// soapCall invokes the action on the ConnectionManager service, returning the output arguments.
+func (c *Client) soapCall(ctx context.Context, action string, envelope []byte) (map[string]string, error) {
+	sc := soap.Client{HTTPClient: c.HTTPClient}
+	return sc.InvokeEnvelope(ctx, c.controlURL, c.ServiceType, action, envelope)
+}
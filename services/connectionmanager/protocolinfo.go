@@ -0,0 +1,109 @@
+package connectionmanager
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidProtocolInfo = errors.New("invalid protocolInfo")
+
+// ProtocolInfo is a parsed protocolInfo string, which describes a
+// transport protocol and media format supported by a device, in the form
+// "<protocol>:<network>:<contentFormat>:<additionalInfo>"
+// e.g. "http-get:*:audio/mpeg:DLNA.ORG_PN=MP3;DLNA.ORG_OP=01"
+type ProtocolInfo struct {
+	// Transport protocol, e.g. "http-get" or "rtsp-rtp-udp"
+	Protocol string
+
+	// Network the protocol applies to, usually "*"
+	Network string
+
+	// MIME type of the media, e.g. "audio/mpeg", or "*" for any
+	MIMEType string
+
+	// Protocol specific additional info, usually "*" or DLNA parameters
+	AdditionalInfo string
+
+	// DLNA.ORG_PN: DLNA media format profile, e.g. "MP3"
+	DLNAProfile string
+
+	// DLNA.ORG_OP: DLNA seek operations, e.g. "01"
+	DLNAOperation string
+
+	// DLNA.ORG_CI: DLNA conversion indicator, "1" if transcoded
+	DLNAConversionIndicator string
+
+	// DLNA.ORG_FLAGS: DLNA flags as a hex string
+	DLNAFlags string
+}
+
+// ParseProtocolInfo parses a single protocolInfo string
+func ParseProtocolInfo(s string) (ProtocolInfo, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 4)
+	if len(parts) != 4 || parts[0] == "" || parts[2] == "" {
+		return ProtocolInfo{}, ErrInvalidProtocolInfo
+	}
+
+	p := ProtocolInfo{
+		Protocol:       parts[0],
+		Network:        parts[1],
+		MIMEType:       parts[2],
+		AdditionalInfo: parts[3],
+	}
+	for _, param := range strings.Split(p.AdditionalInfo, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToUpper(key) {
+		case "DLNA.ORG_PN":
+			p.DLNAProfile = value
+		case "DLNA.ORG_OP":
+			p.DLNAOperation = value
+		case "DLNA.ORG_CI":
+			p.DLNAConversionIndicator = value
+		case "DLNA.ORG_FLAGS":
+			p.DLNAFlags = value
+		}
+	}
+	return p, nil
+}
+
+// ParseProtocolInfoList parses a comma-separated list of protocolInfo strings,
+// as returned by GetProtocolInfo. Invalid entries are skipped.
+func ParseProtocolInfoList(s string) []ProtocolInfo {
+	var list []ProtocolInfo
+	for _, entry := range splitProtocolInfoList(s) {
+		if p, err := ParseProtocolInfo(entry); err == nil {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// String returns the protocolInfo string
+func (p ProtocolInfo) String() string {
+	return p.Protocol + ":" + p.Network + ":" + p.MIMEType + ":" + p.AdditionalInfo
+}
+
+// splitProtocolInfoList splits a CSV list, where commas within values are escaped as "\,"
+func splitProtocolInfoList(s string) []string {
+	var entries []string
+	var cur strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == ',':
+			cur.WriteByte(',')
+			i++
+		case s[i] == ',':
+			entries = append(entries, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(s[i])
+		}
+	}
+	if strings.TrimSpace(cur.String()) != "" {
+		entries = append(entries, cur.String())
+	}
+	return entries
+}
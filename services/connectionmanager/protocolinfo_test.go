@@ -0,0 +1,54 @@
+package connectionmanager
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseProtocolInfoList(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		want  []ProtocolInfo
+	}{
+		{
+			`ParseProtocolInfoList Test #1`,
+			`http-get:*:audio/mpeg:DLNA.ORG_PN=MP3;DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=01700000000000000000000000000000,http-get:*:audio/flac:*`,
+			[]ProtocolInfo{
+				{
+					Protocol:                "http-get",
+					Network:                 "*",
+					MIMEType:                "audio/mpeg",
+					AdditionalInfo:          "DLNA.ORG_PN=MP3;DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=01700000000000000000000000000000",
+					DLNAProfile:             "MP3",
+					DLNAOperation:           "01",
+					DLNAConversionIndicator: "0",
+					DLNAFlags:               "01700000000000000000000000000000",
+				},
+				{Protocol: "http-get", Network: "*", MIMEType: "audio/flac", AdditionalInfo: "*"},
+			},
+		},
+		{
+			`ParseProtocolInfoList Test #2`,
+			"\n  http-get:*:video/mp4:*,\n  invalid,rtsp-rtp-udp:*:video/x-example:a=b\\,c,",
+			[]ProtocolInfo{
+				{Protocol: "http-get", Network: "*", MIMEType: "video/mp4", AdditionalInfo: "*"},
+				{Protocol: "rtsp-rtp-udp", Network: "*", MIMEType: "video/x-example", AdditionalInfo: "a=b,c"},
+			},
+		},
+		{
+			`ParseProtocolInfoList Test #3`,
+			``,
+			nil,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out := ParseProtocolInfoList(tc.input)
+			if fmt.Sprintf("%+v", out) != fmt.Sprintf("%+v", tc.want) {
+				t.Fatalf("%s: got: %+v, want: %+v.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
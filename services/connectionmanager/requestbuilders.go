@@ -1,45 +1,34 @@
 package connectionmanager
 
 import (
-	"encoding/xml"
 	"fmt"
-)
 
-type getProtocolInfoEnvelope struct {
-	XMLName             xml.Name            `xml:"s:Envelope"`
-	Schema              string              `xml:"xmlns:s,attr"`
-	Encoding            string              `xml:"s:encodingStyle,attr"`
-	GetProtocolInfoBody getProtocolInfoBody `xml:"s:Body"`
-}
+	"github.com/supersonic-app/go-upnpcast/services"
+	"github.com/supersonic-app/go-upnpcast/soap"
+)
 
-type getProtocolInfoBody struct {
-	XMLName               xml.Name              `xml:"s:Body"`
-	GetProtocolInfoAction getProtocolInfoAction `xml:"u:GetProtocolInfo"`
+func getProtocolInfoSoapBuild(serviceType services.Type) ([]byte, error) {
+	b, err := soap.BuildEnvelope(serviceType, "GetProtocolInfo")
+	if err != nil {
+		return nil, fmt.Errorf("getProtocolInfoSoapBuild error: %w", err)
+	}
+	return b, nil
 }
 
-type getProtocolInfoAction struct {
-	XMLName           xml.Name `xml:"u:GetProtocolInfo"`
-	ConnectionManager string   `xml:"xmlns:u,attr"`
+func getCurrentConnectionIDsSoapBuild(serviceType services.Type) ([]byte, error) {
+	b, err := soap.BuildEnvelope(serviceType, "GetCurrentConnectionIDs")
+	if err != nil {
+		return nil, fmt.Errorf("getCurrentConnectionIDsSoapBuild error: %w", err)
+	}
+	return b, nil
 }
 
-func getProtocolInfoSoapBuild() ([]byte, error) {
-	d := getProtocolInfoEnvelope{
-		XMLName:  xml.Name{},
-		Schema:   "http://schemas.xmlsoap.org/soap/envelope/",
-		Encoding: "http://schemas.xmlsoap.org/soap/encoding/",
-		GetProtocolInfoBody: getProtocolInfoBody{
-			XMLName: xml.Name{},
-			GetProtocolInfoAction: getProtocolInfoAction{
-				XMLName:           xml.Name{},
-				ConnectionManager: "urn:schemas-upnp-org:service:ConnectionManager:1",
-			},
-		},
-	}
-	xmlStart := []byte(`<?xml version="1.0" encoding="utf-8"?>`)
-	b, err := xml.Marshal(d)
+func getCurrentConnectionInfoSoapBuild(serviceType services.Type, connectionID string) ([]byte, error) {
+	b, err := soap.BuildEnvelope(serviceType, "GetCurrentConnectionInfo",
+		soap.Arg{Name: "ConnectionID", Value: connectionID},
+	)
 	if err != nil {
-		return nil, fmt.Errorf("getProtocolInfoSoapBuild Marshal error: %w", err)
+		return nil, fmt.Errorf("getCurrentConnectionInfoSoapBuild error: %w", err)
 	}
-
-	return append(xmlStart, b...), nil
+	return b, nil
 }
@@ -0,0 +1,81 @@
+package connectionmanager
+
+import (
+	"testing"
+
+	"github.com/supersonic-app/go-upnpcast/services"
+)
+
+func TestGetProtocolInfoSoapBuild(t *testing.T) {
+	tt := []struct {
+		name string
+		want string
+	}{
+		{
+			`getProtocolInfoSoapBuild Test #1`,
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:GetProtocolInfo xmlns:u="urn:schemas-upnp-org:service:ConnectionManager:1"></u:GetProtocolInfo></s:Body></s:Envelope>`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := getProtocolInfoSoapBuild(services.ConnectionManager)
+			if err != nil {
+				t.Fatalf("%s: Failed to call getProtocolInfoSoapBuild due to %s", tc.name, err.Error())
+			}
+			if string(out) != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
+
+func TestGetCurrentConnectionIDsSoapBuild(t *testing.T) {
+	tt := []struct {
+		name string
+		want string
+	}{
+		{
+			`getCurrentConnectionIDsSoapBuild Test #1`,
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:GetCurrentConnectionIDs xmlns:u="urn:schemas-upnp-org:service:ConnectionManager:1"></u:GetCurrentConnectionIDs></s:Body></s:Envelope>`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := getCurrentConnectionIDsSoapBuild(services.ConnectionManager)
+			if err != nil {
+				t.Fatalf("%s: Failed to call getCurrentConnectionIDsSoapBuild due to %s", tc.name, err.Error())
+			}
+			if string(out) != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
+
+func TestGetCurrentConnectionInfoSoapBuild(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			`getCurrentConnectionInfoSoapBuild Test #1`,
+			`0`,
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:GetCurrentConnectionInfo xmlns:u="urn:schemas-upnp-org:service:ConnectionManager:1"><ConnectionID>0</ConnectionID></u:GetCurrentConnectionInfo></s:Body></s:Envelope>`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := getCurrentConnectionInfoSoapBuild(services.ConnectionManager, tc.input)
+			if err != nil {
+				t.Fatalf("%s: Failed to call getCurrentConnectionInfoSoapBuild due to %s", tc.name, err.Error())
+			}
+			if string(out) != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
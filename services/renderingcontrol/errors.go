@@ -0,0 +1,13 @@
+package renderingcontrol
+
+import (
+	"github.com/supersonic-app/go-upnpcast/services"
+	"github.com/supersonic-app/go-upnpcast/soap"
+)
+
+// RenderingControl service errors, returned wrapped by Client methods
+// when the device responds to an action with a UPnP error.
+var (
+	ErrInvalidName       = &soap.UPnPError{Code: 701, Description: "Invalid Name", ServiceType: services.RenderingControl}
+	ErrInvalidInstanceID = &soap.UPnPError{Code: 702, Description: "Invalid InstanceID", ServiceType: services.RenderingControl}
+)
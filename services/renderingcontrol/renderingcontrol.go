@@ -1,91 +1,191 @@
 package renderingcontrol
 
 import (
-	"bytes"
 	"context"
-	"encoding/xml"
+	"errors"
 	"fmt"
-	"io"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/supersonic-app/go-upnpcast/internal/utils"
+	"github.com/supersonic-app/go-upnpcast/internal/scpd"
+	"github.com/supersonic-app/go-upnpcast/services"
+	"github.com/supersonic-app/go-upnpcast/soap"
 )
 
 // Client is a client to the device's RenderingControl service
 type Client struct {
 	HTTPClient *http.Client
+
+	// Service type advertised by the device, used in SOAP requests.
+	// Defaults to RenderingControl:1.
+	ServiceType services.Type
+
+	// Service description (SCPD), from which the supported channels and volume range are read.
+	// If nil, it is fetched from SCPDURL on first use. Both are optional.
+	ServiceDescription *scpd.Document
+	SCPDURL            string
+
 	controlURL string
+
+	mu      sync.Mutex
+	scpd    *scpd.Document
+	scpdErr error
+}
+
+// ErrUnknownVolumeRange is returned by VolumeRange if the device does not advertise its volume range
+var ErrUnknownVolumeRange = errors.New("the device does not advertise its volume range")
+
+// Channel is an audio channel of the renderer
+type Channel string
+
+// Channels defined by the RenderingControl spec.
+// Devices usually support only Master; see SupportedChannels.
+const (
+	ChannelMaster        Channel = "Master"
+	ChannelLeftFront     Channel = "LF"
+	ChannelRightFront    Channel = "RF"
+	ChannelCenterFront   Channel = "CF"
+	ChannelLFE           Channel = "LFE"
+	ChannelLeftSurround  Channel = "LS"
+	ChannelRightSurround Channel = "RS"
+	ChannelLeftOfCenter  Channel = "LFC"
+	ChannelRightOfCenter Channel = "RFC"
+	ChannelSurround      Channel = "SD"
+	ChannelSideLeft      Channel = "SL"
+	ChannelSideRight     Channel = "SR"
+	ChannelTop           Channel = "T"
+	ChannelBottom        Channel = "B"
+)
+
+// VolumeRange is the range of volume levels supported by the device
+type VolumeRange struct {
+	Min  int
+	Max  int
+	Step int
+}
+
+// clamp returns the volume level within the range closest to vol
+func (r VolumeRange) clamp(vol int) int {
+	vol = max(r.Min, min(r.Max, vol))
+	if r.Step > 1 {
+		vol = r.Min + (vol-r.Min+r.Step/2)/r.Step*r.Step
+		if vol > r.Max {
+			vol -= r.Step
+		}
+	}
+	return vol
+}
+
+// VolumeDBRange is the range of volumes in decibels supported by the device
+type VolumeDBRange struct {
+	Min float64
+	Max float64
 }
 
 // Should not be used directly. Use device.RenderingControlClient() instead.
 func NewClient(controlURL string) *Client {
 	return &Client{
-		HTTPClient: &http.Client{Timeout: 10 * time.Second},
-		controlURL: controlURL,
+		HTTPClient:  &http.Client{Timeout: 10 * time.Second},
+		ServiceType: services.RenderingControl,
+		controlURL:  controlURL,
 	}
 }
 
-// GetMute returns the mute status for our device
-func (c *Client) GetMute(ctx context.Context) (string, error) {
-	xmlbuilder, err := getMuteSoapBuild()
+// SupportedChannels returns the channels the device supports,
+// as advertised in its service description.
+// Returns only ChannelMaster if the device does not list its channels.
+func (c *Client) SupportedChannels(ctx context.Context) ([]Channel, error) {
+	desc, err := c.serviceDescription(ctx)
 	if err != nil {
-		return "", fmt.Errorf("GetMuteSoapCall build error: %w", err)
+		return nil, fmt.Errorf("SupportedChannels error: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.controlURL, bytes.NewReader(xmlbuilder))
-	if err != nil {
-		return "", fmt.Errorf("GetMuteSoapCall POST error: %w", err)
+	sv := desc.StateVariable("A_ARG_TYPE_Channel")
+	if sv == nil || len(sv.AllowedValues) == 0 {
+		return []Channel{ChannelMaster}, nil
 	}
+	channels := make([]Channel, 0, len(sv.AllowedValues))
+	for _, v := range sv.AllowedValues {
+		channels = append(channels, Channel(strings.TrimSpace(v)))
+	}
+	return channels, nil
+}
 
-	req.Header = http.Header{
-		"SOAPAction":   []string{`"urn:schemas-upnp-org:service:RenderingControl:1#GetMute"`},
-		"content-type": []string{"text/xml"},
-		"charset":      []string{"utf-8"},
-		"Connection":   []string{"close"},
+// serviceDescription returns the service's SCPD, fetching it on first use.
+// A failed fetch is not retried, so that a broken SCPD does not slow down every call.
+func (c *Client) serviceDescription(ctx context.Context) (*scpd.Document, error) {
+	if c.ServiceDescription != nil {
+		return c.ServiceDescription, nil
 	}
 
-	res, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("GetMuteSoapCall Do POST error: %w", err)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.scpd != nil || c.scpdErr != nil {
+		return c.scpd, c.scpdErr
+	}
+	if c.SCPDURL == "" {
+		c.scpdErr = errors.New("no SCPD URL for service")
+		return nil, c.scpdErr
 	}
-	defer res.Body.Close()
 
-	var respGetMute getMuteRespBody
-	if err = xml.NewDecoder(res.Body).Decode(&respGetMute); err != nil {
-		return "", fmt.Errorf("GetMuteSoapCall XML Decode error: %w", err)
+	desc, err := scpd.Fetch(ctx, c.HTTPClient, c.SCPDURL)
+	if err != nil {
+		// the caller's context ending says nothing about the device
+		if ctx.Err() == nil {
+			c.scpdErr = err
+		}
+		return nil, err
 	}
+	c.scpd = desc
+	return desc, nil
+}
 
-	return respGetMute.Body.GetMuteResponse.CurrentMute, nil
+// GetMute returns the mute status for our device
+func (c *Client) GetMute(ctx context.Context) (bool, error) {
+	return c.GetChannelMute(ctx, ChannelMaster)
 }
 
-// GetVolume returns the volume level for our device.
-func (c *Client) GetVolume(ctx context.Context) (int, error) {
-	xmlbuilder, err := getVolumeSoapBuild()
+// GetChannelMute returns the mute status of the given channel
+func (c *Client) GetChannelMute(ctx context.Context, channel Channel) (bool, error) {
+	xmlbuilder, err := getMuteSoapBuild(c.ServiceType, string(channel))
 	if err != nil {
-		return 0, fmt.Errorf("GetVolumeSoapCall build error: %w", err)
+		return false, fmt.Errorf("GetMuteSoapCall build error: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.controlURL, bytes.NewReader(xmlbuilder))
+	res, err := c.soapCall(ctx, "GetMute", xmlbuilder)
 	if err != nil {
-		return 0, fmt.Errorf("GetVolumeSoapCall POST error: %w", err)
+		return false, fmt.Errorf("GetMuteSoapCall error: %w", err)
 	}
 
-	req.Header = utils.BuildRequestHeader(`"urn:schemas-upnp-org:service:RenderingControl:1#GetVolume"`)
+	muted, err := parseBool(res["CurrentMute"])
+	if err != nil {
+		return false, fmt.Errorf("GetMuteSoapCall failed to parse mute value: %w", err)
+	}
+	return muted, nil
+}
 
-	res, err := c.HTTPClient.Do(req)
+// GetVolume returns the volume level for our device.
+func (c *Client) GetVolume(ctx context.Context) (int, error) {
+	return c.GetChannelVolume(ctx, ChannelMaster)
+}
+
+// GetChannelVolume returns the volume level of the given channel.
+func (c *Client) GetChannelVolume(ctx context.Context, channel Channel) (int, error) {
+	xmlbuilder, err := getVolumeSoapBuild(c.ServiceType, string(channel))
 	if err != nil {
-		return 0, fmt.Errorf("GetVolumeSoapCall Do POST error: %w", err)
+		return 0, fmt.Errorf("GetVolumeSoapCall build error: %w", err)
 	}
-	defer res.Body.Close()
 
-	var respGetVolume getVolumeRespBody
-	if err = xml.NewDecoder(res.Body).Decode(&respGetVolume); err != nil {
-		return 0, fmt.Errorf("GetVolumeSoapCall XML Decode error: %w", err)
+	res, err := c.soapCall(ctx, "GetVolume", xmlbuilder)
+	if err != nil {
+		return 0, fmt.Errorf("GetVolumeSoapCall error: %w", err)
 	}
 
-	intVolume, err := strconv.Atoi(respGetVolume.Body.GetVolumeResponse.CurrentVolume)
+	intVolume, err := strconv.Atoi(strings.TrimSpace(res["CurrentVolume"]))
 	if err != nil {
 		return 0, fmt.Errorf("GetVolumeSoapCall failed to parse volume value: %w", err)
 	}
@@ -99,51 +199,438 @@ func (c *Client) GetVolume(ctx context.Context) (int, error) {
 
 // SetMute sets the mute status of the device
 func (c *Client) SetMute(ctx context.Context, muted bool) error {
-	xmlbuilder, err := setMuteSoapBuild(muted)
+	return c.SetChannelMute(ctx, ChannelMaster, muted)
+}
+
+// SetChannelMute sets the mute status of the given channel
+func (c *Client) SetChannelMute(ctx context.Context, channel Channel, muted bool) error {
+	xmlbuilder, err := setMuteSoapBuild(c.ServiceType, string(channel), muted)
 	if err != nil {
 		return fmt.Errorf("SetMuteSoapCall build error: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.controlURL, bytes.NewReader(xmlbuilder))
+	if _, err := c.soapCall(ctx, "SetMute", xmlbuilder); err != nil {
+		return fmt.Errorf("SetMuteSoapCall error: %w", err)
+	}
+
+	return nil
+}
+
+// SetVolume sets the desired volume level.
+// The level is clamped to the device's supported range, if it advertises one; see VolumeRange.
+func (c *Client) SetVolume(ctx context.Context, vol int) error {
+	return c.SetChannelVolume(ctx, ChannelMaster, vol)
+}
+
+// SetChannelVolume sets the desired volume level of the given channel.
+// The level is clamped to the device's supported range, if it advertises one; see VolumeRange.
+func (c *Client) SetChannelVolume(ctx context.Context, channel Channel, vol int) error {
+	if r, err := c.VolumeRange(ctx); err == nil {
+		vol = r.clamp(vol)
+	}
+	v := strconv.Itoa(vol)
+	xmlbuilder, err := setVolumeSoapBuild(c.ServiceType, string(channel), v)
+	if err != nil {
+		return fmt.Errorf("SetVolumeSoapCall build error: %w", err)
+	}
+
+	if _, err := c.soapCall(ctx, "SetVolume", xmlbuilder); err != nil {
+		return fmt.Errorf("SetVolumeSoapCall error: %w", err)
+	}
+
+	return nil
+}
+
+// VolumeUp raises the volume by step, staying within the device's supported range,
+// and returns the new volume level. If step is not positive, the range's step is used,
+// or 1 if the device does not advertise its range.
+func (c *Client) VolumeUp(ctx context.Context, step int) (int, error) {
+	return c.changeVolume(ctx, ChannelMaster, step, 1)
+}
+
+// VolumeDown lowers the volume by step, staying within the device's supported range,
+// and returns the new volume level. If step is not positive, the range's step is used,
+// or 1 if the device does not advertise its range.
+func (c *Client) VolumeDown(ctx context.Context, step int) (int, error) {
+	return c.changeVolume(ctx, ChannelMaster, step, -1)
+}
+
+func (c *Client) changeVolume(ctx context.Context, channel Channel, step, sign int) (int, error) {
+	r, rangeErr := c.VolumeRange(ctx)
+	if step <= 0 {
+		step = max(r.Step, 1)
+	}
+
+	vol, err := c.GetChannelVolume(ctx, channel)
+	if err != nil {
+		return 0, err
+	}
+	vol += sign * step
+	if rangeErr == nil {
+		vol = r.clamp(vol)
+	} else {
+		// without a range only the lower bound of the unsigned Volume type is known
+		vol = max(vol, 0)
+	}
+	if err := c.SetChannelVolume(ctx, channel, vol); err != nil {
+		return 0, err
+	}
+	return vol, nil
+}
+
+// VolumeRange returns the range of volume levels supported by the device,
+// as advertised in its service description.
+// Returns ErrUnknownVolumeRange if the description has no usable range for Volume.
+func (c *Client) VolumeRange(ctx context.Context) (VolumeRange, error) {
+	desc, err := c.serviceDescription(ctx)
+	if err != nil {
+		return VolumeRange{}, fmt.Errorf("VolumeRange error: %w: %w", ErrUnknownVolumeRange, err)
+	}
+
+	sv := desc.StateVariable("Volume")
+	if sv == nil || sv.AllowedValueRange == nil || sv.AllowedValueRange.Maximum <= sv.AllowedValueRange.Minimum {
+		return VolumeRange{}, fmt.Errorf("VolumeRange error: %w", ErrUnknownVolumeRange)
+	}
+	r := sv.AllowedValueRange
+	return VolumeRange{Min: r.Minimum, Max: r.Maximum, Step: r.Step}, nil
+}
+
+// ListPresets returns the names of the presets that can be selected with SelectPreset,
+// e.g. "FactoryDefaults"
+func (c *Client) ListPresets(ctx context.Context) ([]string, error) {
+	xmlbuilder, err := listPresetsSoapBuild(c.ServiceType)
+	if err != nil {
+		return nil, fmt.Errorf("ListPresetsSoapCall build error: %w", err)
+	}
+
+	res, err := c.soapCall(ctx, "ListPresets", xmlbuilder)
 	if err != nil {
-		return fmt.Errorf("SetMuteSoapCall POST error: %w", err)
+		return nil, fmt.Errorf("ListPresetsSoapCall error: %w", err)
 	}
 
-	req.Header = utils.BuildRequestHeader(`"urn:schemas-upnp-org:service:RenderingControl:1#SetMute"`)
+	var presets []string
+	for _, p := range strings.Split(res["CurrentPresetNameList"], ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			presets = append(presets, p)
+		}
+	}
+	return presets, nil
+}
 
-	res, err := c.HTTPClient.Do(req)
+// SelectPreset restores the rendering settings saved in the named preset
+func (c *Client) SelectPreset(ctx context.Context, presetName string) error {
+	xmlbuilder, err := selectPresetSoapBuild(c.ServiceType, presetName)
 	if err != nil {
-		return fmt.Errorf("SetMuteSoapCall Do POST error: %w", err)
+		return fmt.Errorf("SelectPresetSoapCall build error: %w", err)
 	}
-	defer res.Body.Close()
 
-	if _, err := io.ReadAll(res.Body); err != nil {
-		return fmt.Errorf("SetMuteSoapCall Failed to read response: %w", err)
+	if _, err := c.soapCall(ctx, "SelectPreset", xmlbuilder); err != nil {
+		return fmt.Errorf("SelectPresetSoapCall error: %w", err)
 	}
 
 	return nil
 }
 
-// SetVolume sets the desired volume level.
-func (c *Client) SetVolume(ctx context.Context, vol int) error {
-	v := strconv.Itoa(vol)
-	xmlbuilder, err := setVolumeSoapBuild(v)
+// GetVolumeDB returns the volume of the device in decibels
+func (c *Client) GetVolumeDB(ctx context.Context) (float64, error) {
+	return c.GetChannelVolumeDB(ctx, ChannelMaster)
+}
+
+// GetChannelVolumeDB returns the volume of the given channel in decibels
+func (c *Client) GetChannelVolumeDB(ctx context.Context, channel Channel) (float64, error) {
+	xmlbuilder, err := getVolumeDBSoapBuild(c.ServiceType, string(channel))
 	if err != nil {
-		return fmt.Errorf("SetVolumeSoapCall build error: %w", err)
+		return 0, fmt.Errorf("GetVolumeDBSoapCall build error: %w", err)
+	}
+
+	res, err := c.soapCall(ctx, "GetVolumeDB", xmlbuilder)
+	if err != nil {
+		return 0, fmt.Errorf("GetVolumeDBSoapCall error: %w", err)
+	}
+
+	db, err := parseVolumeDB(res["CurrentVolume"])
+	if err != nil {
+		return 0, fmt.Errorf("GetVolumeDBSoapCall failed to parse volume value: %w", err)
+	}
+	return db, nil
+}
+
+// SetVolumeDB sets the volume of the device in decibels.
+// The device supports a resolution of 1/256 dB; see GetVolumeDBRange for the supported range.
+func (c *Client) SetVolumeDB(ctx context.Context, db float64) error {
+	return c.SetChannelVolumeDB(ctx, ChannelMaster, db)
+}
+
+// SetChannelVolumeDB sets the volume of the given channel in decibels.
+func (c *Client) SetChannelVolumeDB(ctx context.Context, channel Channel, db float64) error {
+	xmlbuilder, err := setVolumeDBSoapBuild(c.ServiceType, string(channel), formatVolumeDB(db))
+	if err != nil {
+		return fmt.Errorf("SetVolumeDBSoapCall build error: %w", err)
+	}
+
+	if _, err := c.soapCall(ctx, "SetVolumeDB", xmlbuilder); err != nil {
+		return fmt.Errorf("SetVolumeDBSoapCall error: %w", err)
+	}
+
+	return nil
+}
+
+// GetVolumeDBRange returns the range of volumes in decibels supported by SetVolumeDB
+func (c *Client) GetVolumeDBRange(ctx context.Context) (VolumeDBRange, error) {
+	return c.GetChannelVolumeDBRange(ctx, ChannelMaster)
+}
+
+// GetChannelVolumeDBRange returns the range of volumes in decibels supported by SetChannelVolumeDB
+func (c *Client) GetChannelVolumeDBRange(ctx context.Context, channel Channel) (VolumeDBRange, error) {
+	xmlbuilder, err := getVolumeDBRangeSoapBuild(c.ServiceType, string(channel))
+	if err != nil {
+		return VolumeDBRange{}, fmt.Errorf("GetVolumeDBRangeSoapCall build error: %w", err)
+	}
+
+	res, err := c.soapCall(ctx, "GetVolumeDBRange", xmlbuilder)
+	if err != nil {
+		return VolumeDBRange{}, fmt.Errorf("GetVolumeDBRangeSoapCall error: %w", err)
+	}
+
+	min, err := parseVolumeDB(res["MinValue"])
+	if err != nil {
+		return VolumeDBRange{}, fmt.Errorf("GetVolumeDBRangeSoapCall failed to parse min value: %w", err)
+	}
+	max, err := parseVolumeDB(res["MaxValue"])
+	if err != nil {
+		return VolumeDBRange{}, fmt.Errorf("GetVolumeDBRangeSoapCall failed to parse max value: %w", err)
+	}
+	return VolumeDBRange{Min: min, Max: max}, nil
+}
+
+// GetLoudness returns whether loudness compensation is enabled
+func (c *Client) GetLoudness(ctx context.Context) (bool, error) {
+	return c.GetChannelLoudness(ctx, ChannelMaster)
+}
+
+// GetChannelLoudness returns whether loudness compensation is enabled for the given channel
+func (c *Client) GetChannelLoudness(ctx context.Context, channel Channel) (bool, error) {
+	xmlbuilder, err := getLoudnessSoapBuild(c.ServiceType, string(channel))
+	if err != nil {
+		return false, fmt.Errorf("GetLoudnessSoapCall build error: %w", err)
+	}
+
+	res, err := c.soapCall(ctx, "GetLoudness", xmlbuilder)
+	if err != nil {
+		return false, fmt.Errorf("GetLoudnessSoapCall error: %w", err)
+	}
+
+	loudness, err := parseBool(res["CurrentLoudness"])
+	if err != nil {
+		return false, fmt.Errorf("GetLoudnessSoapCall failed to parse loudness value: %w", err)
+	}
+	return loudness, nil
+}
+
+// SetLoudness enables or disables loudness compensation
+func (c *Client) SetLoudness(ctx context.Context, loudness bool) error {
+	return c.SetChannelLoudness(ctx, ChannelMaster, loudness)
+}
+
+// SetChannelLoudness enables or disables loudness compensation for the given channel
+func (c *Client) SetChannelLoudness(ctx context.Context, channel Channel, loudness bool) error {
+	xmlbuilder, err := setLoudnessSoapBuild(c.ServiceType, string(channel), loudness)
+	if err != nil {
+		return fmt.Errorf("SetLoudnessSoapCall build error: %w", err)
+	}
+
+	if _, err := c.soapCall(ctx, "SetLoudness", xmlbuilder); err != nil {
+		return fmt.Errorf("SetLoudnessSoapCall error: %w", err)
+	}
+
+	return nil
+}
+
+// GetBrightness returns the current brightness of the display
+func (c *Client) GetBrightness(ctx context.Context) (int, error) {
+	return c.getVideoSetting(ctx, "Brightness")
+}
+
+// SetBrightness sets the brightness of the display
+func (c *Client) SetBrightness(ctx context.Context, v int) error {
+	return c.setVideoSetting(ctx, "Brightness", v)
+}
+
+// GetContrast returns the current contrast of the display
+func (c *Client) GetContrast(ctx context.Context) (int, error) {
+	return c.getVideoSetting(ctx, "Contrast")
+}
+
+// SetContrast sets the contrast of the display
+func (c *Client) SetContrast(ctx context.Context, v int) error {
+	return c.setVideoSetting(ctx, "Contrast", v)
+}
+
+// GetSharpness returns the current sharpness of the display
+func (c *Client) GetSharpness(ctx context.Context) (int, error) {
+	return c.getVideoSetting(ctx, "Sharpness")
+}
+
+// SetSharpness sets the sharpness of the display
+func (c *Client) SetSharpness(ctx context.Context, v int) error {
+	return c.setVideoSetting(ctx, "Sharpness", v)
+}
+
+// GetRedVideoGain returns the current red video gain of the display
+func (c *Client) GetRedVideoGain(ctx context.Context) (int, error) {
+	return c.getVideoSetting(ctx, "RedVideoGain")
+}
+
+// SetRedVideoGain sets the red video gain of the display
+func (c *Client) SetRedVideoGain(ctx context.Context, v int) error {
+	return c.setVideoSetting(ctx, "RedVideoGain", v)
+}
+
+// GetGreenVideoGain returns the current green video gain of the display
+func (c *Client) GetGreenVideoGain(ctx context.Context) (int, error) {
+	return c.getVideoSetting(ctx, "GreenVideoGain")
+}
+
+// SetGreenVideoGain sets the green video gain of the display
+func (c *Client) SetGreenVideoGain(ctx context.Context, v int) error {
+	return c.setVideoSetting(ctx, "GreenVideoGain", v)
+}
+
+// GetBlueVideoGain returns the current blue video gain of the display
+func (c *Client) GetBlueVideoGain(ctx context.Context) (int, error) {
+	return c.getVideoSetting(ctx, "BlueVideoGain")
+}
+
+// SetBlueVideoGain sets the blue video gain of the display
+func (c *Client) SetBlueVideoGain(ctx context.Context, v int) error {
+	return c.setVideoSetting(ctx, "BlueVideoGain", v)
+}
+
+// GetRedVideoBlackLevel returns the current red video black level of the display
+func (c *Client) GetRedVideoBlackLevel(ctx context.Context) (int, error) {
+	return c.getVideoSetting(ctx, "RedVideoBlackLevel")
+}
+
+// SetRedVideoBlackLevel sets the red video black level of the display
+func (c *Client) SetRedVideoBlackLevel(ctx context.Context, v int) error {
+	return c.setVideoSetting(ctx, "RedVideoBlackLevel", v)
+}
+
+// GetGreenVideoBlackLevel returns the current green video black level of the display
+func (c *Client) GetGreenVideoBlackLevel(ctx context.Context) (int, error) {
+	return c.getVideoSetting(ctx, "GreenVideoBlackLevel")
+}
+
+// SetGreenVideoBlackLevel sets the green video black level of the display
+func (c *Client) SetGreenVideoBlackLevel(ctx context.Context, v int) error {
+	return c.setVideoSetting(ctx, "GreenVideoBlackLevel", v)
+}
+
+// GetBlueVideoBlackLevel returns the current blue video black level of the display
+func (c *Client) GetBlueVideoBlackLevel(ctx context.Context) (int, error) {
+	return c.getVideoSetting(ctx, "BlueVideoBlackLevel")
+}
+
+// SetBlueVideoBlackLevel sets the blue video black level of the display
+func (c *Client) SetBlueVideoBlackLevel(ctx context.Context, v int) error {
+	return c.setVideoSetting(ctx, "BlueVideoBlackLevel", v)
+}
+
+// GetColorTemperature returns the current color temperature of the display
+func (c *Client) GetColorTemperature(ctx context.Context) (int, error) {
+	return c.getVideoSetting(ctx, "ColorTemperature")
+}
+
+// SetColorTemperature sets the color temperature of the display
+func (c *Client) SetColorTemperature(ctx context.Context, v int) error {
+	return c.setVideoSetting(ctx, "ColorTemperature", v)
+}
+
+// GetHorizontalKeystone returns the current horizontal keystone of the display
+func (c *Client) GetHorizontalKeystone(ctx context.Context) (int, error) {
+	return c.getVideoSetting(ctx, "HorizontalKeystone")
+}
+
+// SetHorizontalKeystone sets the horizontal keystone of the display
+func (c *Client) SetHorizontalKeystone(ctx context.Context, v int) error {
+	return c.setVideoSetting(ctx, "HorizontalKeystone", v)
+}
+
+// GetVerticalKeystone returns the current vertical keystone of the display
+func (c *Client) GetVerticalKeystone(ctx context.Context) (int, error) {
+	return c.getVideoSetting(ctx, "VerticalKeystone")
+}
+
+// SetVerticalKeystone sets the vertical keystone of the display
+func (c *Client) SetVerticalKeystone(ctx context.Context, v int) error {
+	return c.setVideoSetting(ctx, "VerticalKeystone", v)
+}
+
+func (c *Client) getVideoSetting(ctx context.Context, setting string) (int, error) {
+	action := "Get" + setting
+	xmlbuilder, err := getVideoSettingSoapBuild(c.ServiceType, setting)
+	if err != nil {
+		return 0, fmt.Errorf("%sSoapCall build error: %w", action, err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.controlURL, bytes.NewReader(xmlbuilder))
+	res, err := c.soapCall(ctx, action, xmlbuilder)
 	if err != nil {
-		return fmt.Errorf("SetVolumeSoapCall POST error: %w", err)
+		return 0, fmt.Errorf("%sSoapCall error: %w", action, err)
 	}
 
-	req.Header = utils.BuildRequestHeader(`"urn:schemas-upnp-org:service:RenderingControl:1#SetVolume"`)
+	v, ok := res["Current"+setting]
+	if !ok {
+		return 0, fmt.Errorf("%sSoapCall response missing Current%s", action, setting)
+	}
+	val, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return 0, fmt.Errorf("%sSoapCall failed to parse value: %w", action, err)
+	}
+	return val, nil
+}
 
-	res, err := c.HTTPClient.Do(req)
+func (c *Client) setVideoSetting(ctx context.Context, setting string, v int) error {
+	action := "Set" + setting
+	xmlbuilder, err := setVideoSettingSoapBuild(c.ServiceType, setting, strconv.Itoa(v))
 	if err != nil {
-		return fmt.Errorf("SetVolumeSoapCall Do POST error: %w", err)
+		return fmt.Errorf("%sSoapCall build error: %w", action, err)
+	}
+
+	if _, err := c.soapCall(ctx, action, xmlbuilder); err != nil {
+		return fmt.Errorf("%sSoapCall error: %w", action, err)
 	}
-	defer res.Body.Close()
 
 	return nil
 }
+
+// soapCall invokes the action on the RenderingControl service, returning the output arguments.
+func (c *Client) soapCall(ctx context.Context, action string, envelope []byte) (map[string]string, error) {
+	sc := soap.Client{HTTPClient: c.HTTPClient}
+	return sc.InvokeEnvelope(ctx, c.controlURL, c.ServiceType, action, envelope)
+}
+
+// parseVolumeDB parses a VolumeDB value in units of 1/256 dB into decibels
+func parseVolumeDB(s string) (float64, error) {
+	v, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return float64(v) / 256, nil
+}
+
+// formatVolumeDB formats decibels as a VolumeDB value in units of 1/256 dB
+func formatVolumeDB(db float64) string {
+	return strconv.Itoa(int(math.Round(db * 256)))
+}
+
+// parseBool parses a boolean state variable, tolerating the
+// different representations used by vendors
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "true", "yes", "on":
+		return true, nil
+	case "0", "false", "no", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean value: %q", s)
+}
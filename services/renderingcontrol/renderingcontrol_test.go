@@ -0,0 +1,196 @@
+package renderingcontrol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetVideoSetting(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:GetContrastResponse xmlns:u="urn:schemas-upnp-org:service:RenderingControl:1"><CurrentContrast>42</CurrentContrast></u:GetContrastResponse></s:Body></s:Envelope>`))
+	}))
+	defer srv.Close()
+
+	out, err := NewClient(srv.URL).GetContrast(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to call GetContrast due to %s", err.Error())
+	}
+	if out != 42 {
+		t.Fatalf("GetContrast: got: %d, want: 42.", out)
+	}
+}
+
+func TestSupportedChannels(t *testing.T) {
+	tt := []struct {
+		name string
+		scpd string
+		want string
+	}{
+		{
+			`SupportedChannels Test #1`,
+			`<stateVariable sendEvents="no"><name>A_ARG_TYPE_Channel</name><dataType>string</dataType><allowedValueList><allowedValue>Master</allowedValue><allowedValue>LF</allowedValue><allowedValue>RF</allowedValue></allowedValueList></stateVariable>`,
+			`[Master LF RF]`,
+		},
+		{
+			`SupportedChannels Test #2`,
+			`<stateVariable sendEvents="no"><name>A_ARG_TYPE_Channel</name><dataType>string</dataType></stateVariable>`,
+			`[Master]`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`<?xml version="1.0"?><scpd xmlns="urn:schemas-upnp-org:service-1-0"><serviceStateTable>` + tc.scpd + `</serviceStateTable></scpd>`))
+			}))
+			defer srv.Close()
+
+			c := NewClient(srv.URL + "/control")
+			c.SCPDURL = srv.URL + "/scpd.xml"
+			out, err := c.SupportedChannels(context.Background())
+			if err != nil {
+				t.Fatalf("%s: Failed to call SupportedChannels due to %s", tc.name, err.Error())
+			}
+			if got := fmt.Sprint(out); got != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestVolumeRangeClamp(t *testing.T) {
+	tt := []struct {
+		name  string
+		r     VolumeRange
+		input int
+		want  int
+	}{
+		{`clamp Test #1`, VolumeRange{Min: 0, Max: 100, Step: 1}, 150, 100},
+		{`clamp Test #2`, VolumeRange{Min: 0, Max: 100, Step: 1}, -5, 0},
+		{`clamp Test #3`, VolumeRange{Min: 0, Max: 30, Step: 5}, 12, 10},
+		{`clamp Test #4`, VolumeRange{Min: 0, Max: 32, Step: 5}, 33, 30},
+		{`clamp Test #5`, VolumeRange{Min: 10, Max: 60, Step: 1}, 42, 42},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if out := tc.r.clamp(tc.input); out != tc.want {
+				t.Fatalf("%s: got: %d, want: %d.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
+
+func TestVolumeUp(t *testing.T) {
+	var setVolume string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/scpd.xml" {
+			w.Write([]byte(`<?xml version="1.0"?><scpd xmlns="urn:schemas-upnp-org:service-1-0"><serviceStateTable><stateVariable sendEvents="no"><name>Volume</name><dataType>ui2</dataType><allowedValueRange><minimum>0</minimum><maximum>30</maximum><step>1</step></allowedValueRange></stateVariable></serviceStateTable></scpd>`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case strings.Contains(r.Header.Get("SOAPAction"), "#GetVolume"):
+			w.Write([]byte(`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:GetVolumeResponse xmlns:u="urn:schemas-upnp-org:service:RenderingControl:1"><CurrentVolume>28</CurrentVolume></u:GetVolumeResponse></s:Body></s:Envelope>`))
+		case strings.Contains(r.Header.Get("SOAPAction"), "#SetVolume"):
+			setVolume = string(body)
+			w.Write([]byte(`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:SetVolumeResponse xmlns:u="urn:schemas-upnp-org:service:RenderingControl:1"></u:SetVolumeResponse></s:Body></s:Envelope>`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL + "/control")
+	c.SCPDURL = srv.URL + "/scpd.xml"
+	out, err := c.VolumeUp(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("Failed to call VolumeUp due to %s", err.Error())
+	}
+	if out != 30 {
+		t.Fatalf("VolumeUp: got: %d, want: 30.", out)
+	}
+	if !strings.Contains(setVolume, "<DesiredVolume>30</DesiredVolume>") {
+		t.Fatalf("VolumeUp: got SetVolume request: %s, want DesiredVolume 30.", setVolume)
+	}
+}
+
+func TestSetVolumeUnknownRange(t *testing.T) {
+	tt := []struct {
+		name string
+		scpd string
+	}{
+		{`SetVolume no SCPD`, ``},
+		{`SetVolume no Volume range`, `<?xml version="1.0"?><scpd xmlns="urn:schemas-upnp-org:service-1-0"><serviceStateTable><stateVariable sendEvents="no"><name>Volume</name><dataType>ui2</dataType></stateVariable></serviceStateTable></scpd>`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var scpdRequests int
+			var setVolume string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/scpd.xml" {
+					scpdRequests++
+					if tc.scpd == "" {
+						http.NotFound(w, r)
+						return
+					}
+					w.Write([]byte(tc.scpd))
+					return
+				}
+				body, _ := io.ReadAll(r.Body)
+				setVolume = string(body)
+				w.Write([]byte(`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:SetVolumeResponse xmlns:u="urn:schemas-upnp-org:service:RenderingControl:1"></u:SetVolumeResponse></s:Body></s:Envelope>`))
+			}))
+			defer srv.Close()
+
+			c := NewClient(srv.URL + "/control")
+			c.SCPDURL = srv.URL + "/scpd.xml"
+			if _, err := c.VolumeRange(context.Background()); !errors.Is(err, ErrUnknownVolumeRange) {
+				t.Fatalf("%s: got error: %v, want: %v.", tc.name, err, ErrUnknownVolumeRange)
+			}
+			for i := 0; i < 2; i++ {
+				if err := c.SetVolume(context.Background(), 150); err != nil {
+					t.Fatalf("%s: Failed to call SetVolume due to %s", tc.name, err.Error())
+				}
+			}
+			if !strings.Contains(setVolume, "<DesiredVolume>150</DesiredVolume>") {
+				t.Fatalf("%s: got SetVolume request: %s, want DesiredVolume 150.", tc.name, setVolume)
+			}
+			if scpdRequests != 1 {
+				t.Fatalf("%s: got SCPD requests: %d, want: 1.", tc.name, scpdRequests)
+			}
+		})
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	tt := []struct {
+		name    string
+		input   string
+		want    bool
+		wantErr bool
+	}{
+		{`parseBool Test #1`, "1", true, false},
+		{`parseBool Test #2`, "True", true, false},
+		{`parseBool Test #3`, " false ", false, false},
+		{`parseBool Test #4`, "0", false, false},
+		{`parseBool Test #5`, "maybe", false, true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := parseBool(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("%s: got error: %v, want error: %t.", tc.name, err, tc.wantErr)
+			}
+			if out != tc.want {
+				t.Fatalf("%s: got: %t, want: %t.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
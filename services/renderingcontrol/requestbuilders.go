@@ -1,187 +1,80 @@
 package renderingcontrol
 
 import (
-	"encoding/xml"
-	"fmt"
+	"github.com/supersonic-app/go-upnpcast/services"
+	"github.com/supersonic-app/go-upnpcast/soap"
 )
 
-type setMuteEnvelope struct {
-	XMLName     xml.Name    `xml:"s:Envelope"`
-	Schema      string      `xml:"xmlns:s,attr"`
-	Encoding    string      `xml:"s:encodingStyle,attr"`
-	SetMuteBody setMuteBody `xml:"s:Body"`
+func setMuteSoapBuild(serviceType services.Type, channel string, muted bool) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "SetMute",
+		soap.Arg{Name: "Channel", Value: channel},
+		soap.Arg{Name: "DesiredMute", Value: formatBool(muted)},
+	)
 }
 
-type setMuteBody struct {
-	XMLName       xml.Name      `xml:"s:Body"`
-	SetMuteAction setMuteAction `xml:"u:SetMute"`
+func getMuteSoapBuild(serviceType services.Type, channel string) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "GetMute", soap.Arg{Name: "Channel", Value: channel})
 }
 
-type setMuteAction struct {
-	XMLName          xml.Name `xml:"u:SetMute"`
-	RenderingControl string   `xml:"xmlns:u,attr"`
-	InstanceID       string
-	Channel          string
-	DesiredMute      string
+func getVolumeSoapBuild(serviceType services.Type, channel string) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "GetVolume", soap.Arg{Name: "Channel", Value: channel})
 }
 
-type getMuteEnvelope struct {
-	XMLName     xml.Name    `xml:"s:Envelope"`
-	Schema      string      `xml:"xmlns:s,attr"`
-	Encoding    string      `xml:"s:encodingStyle,attr"`
-	GetMuteBody getMuteBody `xml:"s:Body"`
+func setVolumeSoapBuild(serviceType services.Type, channel, v string) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "SetVolume",
+		soap.Arg{Name: "Channel", Value: channel},
+		soap.Arg{Name: "DesiredVolume", Value: v},
+	)
 }
 
-type getMuteBody struct {
-	XMLName       xml.Name      `xml:"s:Body"`
-	GetMuteAction getMuteAction `xml:"u:GetMute"`
+func listPresetsSoapBuild(serviceType services.Type) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "ListPresets")
 }
 
-type getMuteAction struct {
-	XMLName          xml.Name `xml:"u:GetMute"`
-	RenderingControl string   `xml:"xmlns:u,attr"`
-	InstanceID       string
-	Channel          string
+func selectPresetSoapBuild(serviceType services.Type, presetName string) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "SelectPreset", soap.Arg{Name: "PresetName", Value: presetName})
 }
 
-type getVolumeEnvelope struct {
-	XMLName       xml.Name      `xml:"s:Envelope"`
-	Schema        string        `xml:"xmlns:s,attr"`
-	Encoding      string        `xml:"s:encodingStyle,attr"`
-	GetVolumeBody getVolumeBody `xml:"s:Body"`
+func getVolumeDBSoapBuild(serviceType services.Type, channel string) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "GetVolumeDB", soap.Arg{Name: "Channel", Value: channel})
 }
 
-type getVolumeBody struct {
-	XMLName         xml.Name        `xml:"s:Body"`
-	GetVolumeAction getVolumeAction `xml:"u:GetVolume"`
+func setVolumeDBSoapBuild(serviceType services.Type, channel, v string) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "SetVolumeDB",
+		soap.Arg{Name: "Channel", Value: channel},
+		soap.Arg{Name: "DesiredVolume", Value: v},
+	)
 }
 
-type getVolumeAction struct {
-	XMLName          xml.Name `xml:"u:GetVolume"`
-	RenderingControl string   `xml:"xmlns:u,attr"`
-	InstanceID       string
-	Channel          string
+func getVolumeDBRangeSoapBuild(serviceType services.Type, channel string) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "GetVolumeDBRange", soap.Arg{Name: "Channel", Value: channel})
 }
 
-type setVolumeEnvelope struct {
-	XMLName       xml.Name      `xml:"s:Envelope"`
-	Schema        string        `xml:"xmlns:s,attr"`
-	Encoding      string        `xml:"s:encodingStyle,attr"`
-	SetVolumeBody setVolumeBody `xml:"s:Body"`
+func getLoudnessSoapBuild(serviceType services.Type, channel string) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "GetLoudness", soap.Arg{Name: "Channel", Value: channel})
 }
 
-type setVolumeBody struct {
-	XMLName         xml.Name        `xml:"s:Body"`
-	SetVolumeAction setVolumeAction `xml:"u:SetVolume"`
+func setLoudnessSoapBuild(serviceType services.Type, channel string, loudness bool) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "SetLoudness",
+		soap.Arg{Name: "Channel", Value: channel},
+		soap.Arg{Name: "DesiredLoudness", Value: formatBool(loudness)},
+	)
 }
 
-type setVolumeAction struct {
-	XMLName          xml.Name `xml:"u:SetVolume"`
-	RenderingControl string   `xml:"xmlns:u,attr"`
-	InstanceID       string
-	Channel          string
-	DesiredVolume    string
-}
-
-func setMuteSoapBuild(muted bool) ([]byte, error) {
-	m := "0"
-	if muted {
-		m = "1"
-	}
-
-	d := setMuteEnvelope{
-		XMLName:  xml.Name{},
-		Schema:   "http://schemas.xmlsoap.org/soap/envelope/",
-		Encoding: "http://schemas.xmlsoap.org/soap/encoding/",
-		SetMuteBody: setMuteBody{
-			XMLName: xml.Name{},
-			SetMuteAction: setMuteAction{
-				XMLName:          xml.Name{},
-				RenderingControl: "urn:schemas-upnp-org:service:RenderingControl:1",
-				InstanceID:       "0",
-				Channel:          "Master",
-				DesiredMute:      m,
-			},
-		},
-	}
-	xmlStart := []byte(`<?xml version="1.0" encoding="utf-8"?>`)
-	b, err := xml.Marshal(d)
-	if err != nil {
-		return nil, fmt.Errorf("setMuteSoapBuild Marshal error: %w", err)
-	}
-
-	return append(xmlStart, b...), nil
-}
+// video settings share the same request shape,
+// differing only in the action and argument names
 
-func getMuteSoapBuild() ([]byte, error) {
-	d := getMuteEnvelope{
-		XMLName:  xml.Name{},
-		Schema:   "http://schemas.xmlsoap.org/soap/envelope/",
-		Encoding: "http://schemas.xmlsoap.org/soap/encoding/",
-		GetMuteBody: getMuteBody{
-			XMLName: xml.Name{},
-			GetMuteAction: getMuteAction{
-				XMLName:          xml.Name{},
-				RenderingControl: "urn:schemas-upnp-org:service:RenderingControl:1",
-				InstanceID:       "0",
-				Channel:          "Master",
-			},
-		},
-	}
-	xmlStart := []byte(`<?xml version="1.0" encoding="utf-8"?>`)
-	b, err := xml.Marshal(d)
-	if err != nil {
-		return nil, fmt.Errorf("getMuteSoapBuild Marshal error: %w", err)
-	}
-
-	return append(xmlStart, b...), nil
+func getVideoSettingSoapBuild(serviceType services.Type, setting string) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "Get"+setting)
 }
 
-func getVolumeSoapBuild() ([]byte, error) {
-	d := getVolumeEnvelope{
-		XMLName:  xml.Name{},
-		Schema:   "http://schemas.xmlsoap.org/soap/envelope/",
-		Encoding: "http://schemas.xmlsoap.org/soap/encoding/",
-		GetVolumeBody: getVolumeBody{
-			XMLName: xml.Name{},
-			GetVolumeAction: getVolumeAction{
-				XMLName:          xml.Name{},
-				RenderingControl: "urn:schemas-upnp-org:service:RenderingControl:1",
-				InstanceID:       "0",
-				Channel:          "Master",
-			},
-		},
-	}
-	xmlStart := []byte(`<?xml version="1.0" encoding="utf-8"?>`)
-	b, err := xml.Marshal(d)
-	if err != nil {
-		return nil, fmt.Errorf("getVolumeSoapBuild Marshal error: %w", err)
-	}
-
-	return append(xmlStart, b...), nil
+func setVideoSettingSoapBuild(serviceType services.Type, setting, v string) ([]byte, error) {
+	return soap.BuildInstanceEnvelope(serviceType, "Set"+setting, soap.Arg{Name: "Desired" + setting, Value: v})
 }
 
-func setVolumeSoapBuild(v string) ([]byte, error) {
-	d := setVolumeEnvelope{
-		XMLName:  xml.Name{},
-		Schema:   "http://schemas.xmlsoap.org/soap/envelope/",
-		Encoding: "http://schemas.xmlsoap.org/soap/encoding/",
-		SetVolumeBody: setVolumeBody{
-			XMLName: xml.Name{},
-			SetVolumeAction: setVolumeAction{
-				XMLName:          xml.Name{},
-				RenderingControl: "urn:schemas-upnp-org:service:RenderingControl:1",
-				InstanceID:       "0",
-				Channel:          "Master",
-				DesiredVolume:    v,
-			},
-		},
+func formatBool(b bool) string {
+	if b {
+		return "1"
 	}
-	xmlStart := []byte(`<?xml version="1.0" encoding="utf-8"?>`)
-	b, err := xml.Marshal(d)
-	if err != nil {
-		return nil, fmt.Errorf("setVolumeSoapBuild Marshal error: %w", err)
-	}
-
-	return append(xmlStart, b...), nil
+	return "0"
 }
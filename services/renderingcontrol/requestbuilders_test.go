@@ -1,6 +1,10 @@
 package renderingcontrol
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/supersonic-app/go-upnpcast/services"
+)
 
 func TestSetMuteSoapBuild(t *testing.T) {
 	tt := []struct {
@@ -22,7 +26,7 @@ func TestSetMuteSoapBuild(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			out, err := setMuteSoapBuild(tc.input)
+			out, err := setMuteSoapBuild(services.RenderingControl, "Master", tc.input)
 			if err != nil {
 				t.Fatalf("%s: Failed to call setMuteSoapBuild due to %s", tc.name, err.Error())
 			}
@@ -46,7 +50,7 @@ func TestGetVolumeSoapBuild(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			out, err := getVolumeSoapBuild()
+			out, err := getVolumeSoapBuild(services.RenderingControl, "Master")
 			if err != nil {
 				t.Fatalf("%s: Failed to call setMuteSoapBuild due to %s", tc.name, err.Error())
 			}
@@ -70,7 +74,7 @@ func TestGetMuteSoapBuild(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			out, err := getMuteSoapBuild()
+			out, err := getMuteSoapBuild(services.RenderingControl, "Master")
 			if err != nil {
 				t.Fatalf("%s: Failed to call getMuteSoapBuild due to %s", tc.name, err.Error())
 			}
@@ -83,20 +87,28 @@ func TestGetMuteSoapBuild(t *testing.T) {
 
 func TestSetVolumeSoapBuild(t *testing.T) {
 	tt := []struct {
-		name   string
-		intput string
-		want   string
+		name    string
+		channel Channel
+		intput  string
+		want    string
 	}{
 		{
 			`setVolumeSoapBuild Test #1`,
+			ChannelMaster,
 			`100`,
 			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:SetVolume xmlns:u="urn:schemas-upnp-org:service:RenderingControl:1"><InstanceID>0</InstanceID><Channel>Master</Channel><DesiredVolume>100</DesiredVolume></u:SetVolume></s:Body></s:Envelope>`,
 		},
+		{
+			`setVolumeSoapBuild Test #2`,
+			ChannelLeftFront,
+			`40`,
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:SetVolume xmlns:u="urn:schemas-upnp-org:service:RenderingControl:1"><InstanceID>0</InstanceID><Channel>LF</Channel><DesiredVolume>40</DesiredVolume></u:SetVolume></s:Body></s:Envelope>`,
+		},
 	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			out, err := setVolumeSoapBuild(tc.intput)
+			out, err := setVolumeSoapBuild(services.RenderingControl, string(tc.channel), tc.intput)
 			if err != nil {
 				t.Fatalf("%s: Failed to call setVolumeSoapBuild due to %s", tc.name, err.Error())
 			}
@@ -106,3 +118,88 @@ func TestSetVolumeSoapBuild(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectPresetSoapBuild(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			`selectPresetSoapBuild Test #1`,
+			"FactoryDefaults",
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:SelectPreset xmlns:u="urn:schemas-upnp-org:service:RenderingControl:1"><InstanceID>0</InstanceID><PresetName>FactoryDefaults</PresetName></u:SelectPreset></s:Body></s:Envelope>`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := selectPresetSoapBuild(services.RenderingControl, tc.input)
+			if err != nil {
+				t.Fatalf("%s: Failed to call selectPresetSoapBuild due to %s", tc.name, err.Error())
+			}
+			if string(out) != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetVolumeDBSoapBuild(t *testing.T) {
+	tt := []struct {
+		name  string
+		input float64
+		want  string
+	}{
+		{
+			`setVolumeDBSoapBuild Test #1`,
+			-10.5,
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:SetVolumeDB xmlns:u="urn:schemas-upnp-org:service:RenderingControl:1"><InstanceID>0</InstanceID><Channel>Master</Channel><DesiredVolume>-2688</DesiredVolume></u:SetVolumeDB></s:Body></s:Envelope>`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := setVolumeDBSoapBuild(services.RenderingControl, "Master", formatVolumeDB(tc.input))
+			if err != nil {
+				t.Fatalf("%s: Failed to call setVolumeDBSoapBuild due to %s", tc.name, err.Error())
+			}
+			if string(out) != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
+
+func TestVideoSettingSoapBuild(t *testing.T) {
+	tt := []struct {
+		name  string
+		build func() ([]byte, error)
+		want  string
+	}{
+		{
+			`videoSettingSoapBuild Test #1`,
+			func() ([]byte, error) { return getVideoSettingSoapBuild(services.RenderingControl, "Brightness") },
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:GetBrightness xmlns:u="urn:schemas-upnp-org:service:RenderingControl:1"><InstanceID>0</InstanceID></u:GetBrightness></s:Body></s:Envelope>`,
+		},
+		{
+			`videoSettingSoapBuild Test #2`,
+			func() ([]byte, error) {
+				return setVideoSettingSoapBuild(services.RenderingControl, "HorizontalKeystone", "-5")
+			},
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:SetHorizontalKeystone xmlns:u="urn:schemas-upnp-org:service:RenderingControl:1"><InstanceID>0</InstanceID><DesiredHorizontalKeystone>-5</DesiredHorizontalKeystone></u:SetHorizontalKeystone></s:Body></s:Envelope>`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := tc.build()
+			if err != nil {
+				t.Fatalf("%s: Failed to call videoSettingSoapBuild due to %s", tc.name, err.Error())
+			}
+			if string(out) != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
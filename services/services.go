@@ -1,9 +1,55 @@
 package services
 
+import (
+	"strconv"
+	"strings"
+)
+
 type Type = string
 
+// Service types of the version 1 services.
+// Devices advertising a later version of a service also implement these,
+// since UPnP service versions are backward compatible; see Implements.
 const (
 	AVTransport       Type = "urn:schemas-upnp-org:service:AVTransport:1"
 	ConnectionManager Type = "urn:schemas-upnp-org:service:ConnectionManager:1"
 	RenderingControl  Type = "urn:schemas-upnp-org:service:RenderingControl:1"
 )
+
+// Version returns the version of the service type,
+// e.g. 2 for "urn:schemas-upnp-org:service:AVTransport:2",
+// or 0 if the type does not end in a valid version
+func Version(t Type) int {
+	_, v := splitVersion(t)
+	return v
+}
+
+// WithVersion returns the service type with its version replaced by v,
+// e.g. WithVersion(AVTransport, 2) is "urn:schemas-upnp-org:service:AVTransport:2"
+func WithVersion(t Type, v int) Type {
+	name, _ := splitVersion(t)
+	return name + ":" + strconv.Itoa(v)
+}
+
+// Implements returns true if a service advertised with the advertised type
+// implements the service type t, i.e. it is the same service with the same or a later version
+func Implements(advertised, t Type) bool {
+	advName, advVersion := splitVersion(advertised)
+	name, version := splitVersion(t)
+	return advVersion > 0 && advName == name && advVersion >= version
+}
+
+// splitVersion splits a service type into its name, e.g. "urn:schemas-upnp-org:service:AVTransport",
+// and its version, or 0 if the version is missing or invalid
+func splitVersion(t Type) (string, int) {
+	t = strings.TrimSpace(t)
+	i := strings.LastIndexByte(t, ':')
+	if i < 0 {
+		return t, 0
+	}
+	v, err := strconv.Atoi(t[i+1:])
+	if err != nil || v < 1 {
+		return t, 0
+	}
+	return t[:i], v
+}
@@ -0,0 +1,48 @@
+package services
+
+import "testing"
+
+func TestImplements(t *testing.T) {
+	tt := []struct {
+		name       string
+		advertised Type
+		t          Type
+		want       bool
+	}{
+		{`Implements same version`, AVTransport, AVTransport, true},
+		{`Implements later version`, "urn:schemas-upnp-org:service:RenderingControl:3", RenderingControl, true},
+		{`Implements earlier version`, AVTransport, "urn:schemas-upnp-org:service:AVTransport:2", false},
+		{`Implements other service`, ConnectionManager, AVTransport, false},
+		{`Implements no version`, "urn:schemas-upnp-org:service:AVTransport", AVTransport, false},
+		{`Implements invalid version`, "urn:schemas-upnp-org:service:AVTransport:x", AVTransport, false},
+	}
+
+	for _, tc := range tt {
+		if got := Implements(tc.advertised, tc.t); got != tc.want {
+			t.Fatalf("%s: got: %v, want: %v.", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestVersion(t *testing.T) {
+	tt := []struct {
+		name string
+		t    Type
+		want int
+	}{
+		{`Version 1`, AVTransport, 1},
+		{`Version 3`, "urn:schemas-upnp-org:service:ConnectionManager:3", 3},
+		{`Version missing`, "urn:schemas-upnp-org:service:ConnectionManager", 0},
+		{`Version zero`, "urn:schemas-upnp-org:service:ConnectionManager:0", 0},
+	}
+
+	for _, tc := range tt {
+		if got := Version(tc.t); got != tc.want {
+			t.Fatalf("%s: got: %v, want: %v.", tc.name, got, tc.want)
+		}
+	}
+
+	if got, want := WithVersion(AVTransport, 2), "urn:schemas-upnp-org:service:AVTransport:2"; got != want {
+		t.Fatalf("WithVersion: got: %v, want: %v.", got, want)
+	}
+}
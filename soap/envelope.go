@@ -0,0 +1,136 @@
+package soap
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidXMLName = errors.New("invalid XML name")
+
+// Arg is a named input argument of an action.
+// Arguments must be passed in the order defined by the service description.
+type Arg struct {
+	Name  string
+	Value string
+}
+
+type responseEnvelope struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Body    *struct {
+		Responses []struct {
+			XMLName xml.Name
+			Args    []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:",any"`
+	} `xml:"Body"`
+}
+
+// Invoke invokes the action on the service at controlURL with the given input arguments,
+// and returns the output arguments of the response by name.
+// It can be used to call actions not covered by the typed service clients,
+// such as vendor-specific actions.
+// If the device responds with a SOAP fault, the returned error is a *UPnPError.
+func (c *Client) Invoke(ctx context.Context, controlURL, serviceType, action string, args ...Arg) (map[string]string, error) {
+	envelope, err := BuildEnvelope(serviceType, action, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.InvokeEnvelope(ctx, controlURL, serviceType, action, envelope)
+}
+
+// InvokeEnvelope is like Invoke, but posts an envelope built with BuildEnvelope
+// or BuildInstanceEnvelope, as done by the typed service clients.
+func (c *Client) InvokeEnvelope(ctx context.Context, controlURL, serviceType, action string, envelope []byte) (map[string]string, error) {
+	body, err := c.Call(ctx, controlURL, serviceType, action, envelope)
+	if err != nil {
+		return nil, err
+	}
+	return ParseResponse(body, action)
+}
+
+// BuildEnvelope returns the SOAP envelope invoking the action with the given input arguments.
+// Argument values are XML-escaped.
+func BuildEnvelope(serviceType, action string, args ...Arg) ([]byte, error) {
+	if !isXMLName(action) {
+		return nil, fmt.Errorf("SOAP build action %q: %w", action, ErrInvalidXMLName)
+	}
+
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?>`)
+	b.WriteString(`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`)
+	b.WriteString(`<u:` + action + ` xmlns:u="`)
+	xml.EscapeText(&b, []byte(serviceType))
+	b.WriteString(`">`)
+	for _, arg := range args {
+		if !isXMLName(arg.Name) {
+			return nil, fmt.Errorf("SOAP build argument %q: %w", arg.Name, ErrInvalidXMLName)
+		}
+		b.WriteString("<" + arg.Name + ">")
+		xml.EscapeText(&b, []byte(arg.Value))
+		b.WriteString("</" + arg.Name + ">")
+	}
+	b.WriteString(`</u:` + action + `></s:Body></s:Envelope>`)
+
+	return b.Bytes(), nil
+}
+
+// BuildInstanceEnvelope is like BuildEnvelope, for an action on instance 0
+// of an AV service, whose first input argument is the InstanceID.
+func BuildInstanceEnvelope(serviceType, action string, args ...Arg) ([]byte, error) {
+	return BuildEnvelope(serviceType, action, append([]Arg{{Name: "InstanceID", Value: "0"}}, args...)...)
+}
+
+// ParseResponse returns the output arguments by name
+// from the body of the response to a successful action.
+// The Body must contain a single {action}Response element.
+func ParseResponse(body []byte, action string) (map[string]string, error) {
+	var env responseEnvelope
+	if err := xml.Unmarshal(body, &env); err != nil {
+		return nil, fmt.Errorf("SOAP %s response decode error: %w", action, err)
+	}
+	if env.Body == nil {
+		return nil, fmt.Errorf("SOAP %s response missing Body", action)
+	}
+
+	// some devices respond with an empty Body for actions without output arguments
+	responses := env.Body.Responses
+	if len(responses) == 0 {
+		return map[string]string{}, nil
+	}
+	if len(responses) > 1 {
+		return nil, fmt.Errorf("SOAP %s response has %d elements in Body", action, len(responses))
+	}
+	if name := responses[0].XMLName.Local; name != action+"Response" {
+		return nil, fmt.Errorf("SOAP %s response has unexpected element %s", action, name)
+	}
+
+	out := make(map[string]string, len(responses[0].Args))
+	for _, arg := range responses[0].Args {
+		out[arg.XMLName.Local] = arg.Value
+	}
+	return out, nil
+}
+
+// isXMLName returns whether s can be used as an element name
+// without namespace prefix
+func isXMLName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r == '-' || r == '.' || r >= '0' && r <= '9'):
+		default:
+			return false
+		}
+	}
+	return !strings.HasPrefix(strings.ToLower(s), "xml")
+}
@@ -0,0 +1,104 @@
+package soap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildEnvelope(t *testing.T) {
+	tt := []struct {
+		name    string
+		action  string
+		args    []Arg
+		want    string
+		wantErr error
+	}{
+		{
+			`BuildEnvelope Test #1`,
+			"X_SetText",
+			[]Arg{{Name: "InstanceID", Value: "0"}, {Name: "Text", Value: `<a href="x">&'`}},
+			`<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><u:X_SetText xmlns:u="urn:schemas-upnp-org:service:AVTransport:1"><InstanceID>0</InstanceID><Text>&lt;a href=&#34;x&#34;&gt;&amp;&#39;</Text></u:X_SetText></s:Body></s:Envelope>`,
+			nil,
+		},
+		{
+			`BuildEnvelope Test #2`,
+			"Play></u:Play",
+			nil,
+			``,
+			ErrInvalidXMLName,
+		},
+		{
+			`BuildEnvelope Test #3`,
+			"Play",
+			[]Arg{{Name: "1Speed", Value: "1"}},
+			``,
+			ErrInvalidXMLName,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := BuildEnvelope("urn:schemas-upnp-org:service:AVTransport:1", tc.action, tc.args...)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("%s: got error: %v, want: %v.", tc.name, err, tc.wantErr)
+			}
+			if string(out) != tc.want {
+				t.Fatalf("%s: got: %s, want: %s.", tc.name, out, tc.want)
+			}
+		})
+	}
+}
+
+func TestInvoke(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("SOAPAction"); got != `"urn:schemas-sonos-com:service:Queue:1#X_GetQueue"` {
+			t.Errorf("Invoke: got SOAPAction: %s", got)
+		}
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:X_GetQueueResponse xmlns:u="urn:schemas-sonos-com:service:Queue:1"><Result>&lt;DIDL-Lite/&gt;</Result><TotalMatches>3</TotalMatches></u:X_GetQueueResponse></s:Body></s:Envelope>`))
+	}))
+	defer srv.Close()
+
+	c := Client{}
+	out, err := c.Invoke(context.Background(), srv.URL, "urn:schemas-sonos-com:service:Queue:1", "X_GetQueue", Arg{Name: "QueueID", Value: "0"})
+	if err != nil {
+		t.Fatalf("Failed to call Invoke due to %s", err.Error())
+	}
+	if out["Result"] != "<DIDL-Lite/>" || out["TotalMatches"] != "3" {
+		t.Fatalf("Invoke: got: %v.", out)
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	const envelope = `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>%s</s:Body></s:Envelope>`
+
+	tt := []struct {
+		name    string
+		body    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{`ParseResponse Test #1`, `<u:GetVolumeResponse xmlns:u="urn:schemas-upnp-org:service:RenderingControl:1"><CurrentVolume>20</CurrentVolume></u:GetVolumeResponse>`, map[string]string{"CurrentVolume": "20"}, false},
+		{`ParseResponse Test #2`, ``, map[string]string{}, false},
+		{`ParseResponse Test #3`, `<u:GetMuteResponse xmlns:u="urn:schemas-upnp-org:service:RenderingControl:1"><CurrentMute>0</CurrentMute></u:GetMuteResponse>`, nil, true},
+		{`ParseResponse Test #4`, `<u:GetVolumeResponse xmlns:u="urn:schemas-upnp-org:service:RenderingControl:1"><CurrentVolume>20</CurrentVolume></u:GetVolumeResponse><u:GetVolumeResponse xmlns:u="urn:schemas-upnp-org:service:RenderingControl:1"><Extra>1</Extra></u:GetVolumeResponse>`, nil, true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseResponse([]byte(strings.Replace(envelope, "%s", tc.body, 1)), "GetVolume")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("%s: got error: %v, want error: %v.", tc.name, err, tc.wantErr)
+			}
+			if !maps.Equal(got, tc.want) {
+				t.Fatalf("%s: got: %v, want: %v.", tc.name, got, tc.want)
+			}
+		})
+	}
+}
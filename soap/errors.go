@@ -0,0 +1,53 @@
+package soap
+
+import (
+	"fmt"
+
+	"github.com/supersonic-app/go-upnpcast/services"
+)
+
+// UPnPError is an error returned by a UPnP service in a SOAP fault response.
+// Use errors.Is to compare against the error code variables
+// in this package and the service packages, or errors.As to access the details.
+type UPnPError struct {
+	// UPnP error code
+	Code int
+
+	// Description of the error as given by the device
+	Description string
+
+	// Type of the service that returned the error, e.g. "urn:schemas-upnp-org:service:AVTransport:1".
+	// Empty for the error variables that are common to all services.
+	ServiceType services.Type
+}
+
+func (e *UPnPError) Error() string {
+	return fmt.Sprintf("UPnP error %d: %s", e.Code, e.Description)
+}
+
+// Is reports whether target is a *UPnPError with the same error code.
+// Codes from 600 up may be defined differently by each service, so if target has
+// a service type, the error must also have been returned by that service, in any version.
+func (e *UPnPError) Is(target error) bool {
+	t, ok := target.(*UPnPError)
+	if !ok || t.Code != e.Code {
+		return false
+	}
+	if t.Code < 600 || t.ServiceType == "" {
+		return true
+	}
+	return services.Implements(e.ServiceType, services.WithVersion(t.ServiceType, 1))
+}
+
+// Standard UPnP action errors, common to all services
+var (
+	ErrInvalidAction                = &UPnPError{Code: 401, Description: "Invalid Action"}
+	ErrInvalidArgs                  = &UPnPError{Code: 402, Description: "Invalid Args"}
+	ErrActionFailed                 = &UPnPError{Code: 501, Description: "Action Failed"}
+	ErrArgumentValueInvalid         = &UPnPError{Code: 600, Description: "Argument Value Invalid"}
+	ErrArgumentValueOutOfRange      = &UPnPError{Code: 601, Description: "Argument Value Out of Range"}
+	ErrOptionalActionNotImplemented = &UPnPError{Code: 602, Description: "Optional Action Not Implemented"}
+	ErrOutOfMemory                  = &UPnPError{Code: 603, Description: "Out of Memory"}
+	ErrHumanInterventionRequired    = &UPnPError{Code: 604, Description: "Human Intervention Required"}
+	ErrStringArgumentTooLong        = &UPnPError{Code: 605, Description: "String Argument Too Long"}
+)
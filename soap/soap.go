@@ -0,0 +1,94 @@
+// Package soap implements the SOAP control protocol used to invoke UPnP service actions.
+package soap
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/supersonic-app/go-upnpcast/internal/utils"
+)
+
+// Client invokes actions on UPnP services
+type Client struct {
+	// HTTP client used for requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+type faultEnvelope struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Body    struct {
+		Fault *struct {
+			FaultCode   string `xml:"faultcode"`
+			FaultString string `xml:"faultstring"`
+			Detail      struct {
+				UPnPError *struct {
+					ErrorCode        int    `xml:"errorCode"`
+					ErrorDescription string `xml:"errorDescription"`
+				} `xml:"UPnPError"`
+			} `xml:"detail"`
+		} `xml:"Fault"`
+	} `xml:"Body"`
+}
+
+// Call posts the SOAP envelope for the given action to the service's control URL
+// and returns the body of the response.
+// If the device responds with a SOAP fault, the returned error is a *UPnPError.
+func (c *Client) Call(ctx context.Context, controlURL, serviceType, action string, envelope []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", controlURL, bytes.NewReader(envelope))
+	if err != nil {
+		return nil, fmt.Errorf("SOAP POST error: %w", err)
+	}
+	req.Header = utils.BuildRequestHeader(soapAction(serviceType, action))
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("SOAP Do POST error: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("SOAP Failed to read response: %w", err)
+	}
+
+	// some devices respond to failed actions with a fault but a 200 status
+	if res.StatusCode != http.StatusOK || bytes.Contains(body, []byte("Fault>")) {
+		if err := parseFault(body, serviceType); err != nil {
+			return nil, err
+		}
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("SOAP HTTP error: %s", res.Status)
+	}
+
+	return body, nil
+}
+
+// soapAction returns the quoted SOAPACTION header value for the action
+func soapAction(serviceType, action string) string {
+	return `"` + strings.Trim(serviceType, `"`) + "#" + strings.Trim(action, `"`) + `"`
+}
+
+// parseFault returns the error described by a SOAP fault response body
+// from the given service, or nil if the body is not a SOAP fault.
+func parseFault(body []byte, serviceType string) error {
+	var env faultEnvelope
+	if err := xml.Unmarshal(body, &env); err != nil || env.Body.Fault == nil {
+		return nil
+	}
+
+	fault := env.Body.Fault
+	if upnpErr := fault.Detail.UPnPError; upnpErr != nil {
+		return &UPnPError{Code: upnpErr.ErrorCode, Description: upnpErr.ErrorDescription, ServiceType: serviceType}
+	}
+	return fmt.Errorf("SOAP fault %s: %s", fault.FaultCode, fault.FaultString)
+}
@@ -0,0 +1,113 @@
+package soap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	tt := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error
+	}{
+		{
+			`Call Test #1`,
+			http.StatusOK,
+			`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:PlayResponse xmlns:u="urn:schemas-upnp-org:service:AVTransport:1"/></s:Body></s:Envelope>`,
+			nil,
+		},
+		{
+			`Call Test #2`,
+			http.StatusInternalServerError,
+			`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><s:Fault><faultcode>s:Client</faultcode><faultstring>UPnPError</faultstring><detail><UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>714</errorCode><errorDescription>Illegal MIME-type</errorDescription></UPnPError></detail></s:Fault></s:Body></s:Envelope>`,
+			&UPnPError{Code: 714, Description: "Illegal MIME-type"},
+		},
+		{
+			`Call Test #3`,
+			http.StatusOK,
+			`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><s:Fault><faultcode>s:Client</faultcode><faultstring>UPnPError</faultstring><detail><UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>401</errorCode><errorDescription>Invalid Action</errorDescription></UPnPError></detail></s:Fault></s:Body></s:Envelope>`,
+			ErrInvalidAction,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("SOAPAction"); got != `"urn:schemas-upnp-org:service:AVTransport:1#Play"` {
+					t.Errorf("%s: got SOAPAction: %s", tc.name, got)
+				}
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			c := Client{}
+			out, err := c.Call(context.Background(), srv.URL, "urn:schemas-upnp-org:service:AVTransport:1", "Play", nil)
+			if tc.wantErr == nil {
+				if err != nil {
+					t.Fatalf("%s: Failed to call Call due to %s", tc.name, err.Error())
+				}
+				if string(out) != tc.body {
+					t.Fatalf("%s: got: %s, want: %s.", tc.name, out, tc.body)
+				}
+				return
+			}
+
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("%s: got error: %v, want: %v.", tc.name, err, tc.wantErr)
+			}
+			var upnpErr *UPnPError
+			if !errors.As(err, &upnpErr) || upnpErr.Description != tc.wantErr.(*UPnPError).Description ||
+				upnpErr.ServiceType != "urn:schemas-upnp-org:service:AVTransport:1" {
+				t.Fatalf("%s: got error: %+v, want: %v.", tc.name, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCallHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c := Client{}
+	_, err := c.Call(context.Background(), srv.URL, "urn:schemas-upnp-org:service:AVTransport:1", "Play", nil)
+	var upnpErr *UPnPError
+	if err == nil || errors.As(err, &upnpErr) {
+		t.Fatalf("Call HTTP error: got error: %v, want: HTTP error.", err)
+	}
+}
+
+func TestUPnPErrorIs(t *testing.T) {
+	avTransport := "urn:schemas-upnp-org:service:AVTransport:1"
+	renderingControl := "urn:schemas-upnp-org:service:RenderingControl:1"
+	errTransitionNotAvailable := &UPnPError{Code: 701, ServiceType: avTransport}
+
+	tt := []struct {
+		name   string
+		err    *UPnPError
+		target error
+		want   bool
+	}{
+		{`Is same service`, &UPnPError{Code: 701, ServiceType: avTransport}, errTransitionNotAvailable, true},
+		{`Is later service version`, &UPnPError{Code: 701, ServiceType: "urn:schemas-upnp-org:service:AVTransport:2"}, errTransitionNotAvailable, true},
+		{`Is other service`, &UPnPError{Code: 701, ServiceType: renderingControl}, errTransitionNotAvailable, false},
+		{`Is other code`, &UPnPError{Code: 702, ServiceType: avTransport}, errTransitionNotAvailable, false},
+		{`Is common error`, &UPnPError{Code: 401, ServiceType: renderingControl}, ErrInvalidAction, true},
+		{`Is common 6xx error`, &UPnPError{Code: 602, ServiceType: renderingControl}, ErrOptionalActionNotImplemented, true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := errors.Is(tc.err, tc.target); got != tc.want {
+				t.Fatalf("%s: got: %v, want: %v.", tc.name, got, tc.want)
+			}
+		})
+	}
+}